@@ -0,0 +1,12 @@
+// Command dpfctl calls IIJ DNS Platform Service API.
+package main
+
+import (
+	"os"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/dpfctl"
+)
+
+func main() {
+	os.Exit(dpfctl.Main(os.Args[1:]))
+}
@@ -49,3 +49,12 @@ func (a Action) ToMethod() string {
 	}
 	return ""
 }
+
+// IsMutating returns true, if the action changes resources.
+func (a Action) IsMutating() bool {
+	switch a {
+	case ActionCreate, ActionUpdate, ActionDelete, ActionCancel, ActionApply:
+		return true
+	}
+	return false
+}
@@ -61,4 +61,14 @@ var _ = Describe("Action", func() {
 			})
 		})
 	})
+	Context("IsMutating", func() {
+		It("returns true for mutating actions", func() {
+			for _, action := range []api.Action{api.ActionCreate, api.ActionUpdate, api.ActionDelete, api.ActionCancel, api.ActionApply} {
+				Expect(action.IsMutating()).To(BeTrue(), string(action))
+			}
+			for _, action := range []api.Action{api.ActionRead, api.ActionList, api.ActionCount} {
+				Expect(action.IsMutating()).To(BeFalse(), string(action))
+			}
+		})
+	})
 })
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -16,6 +17,11 @@ import (
 
 const DefaultEndpoint = "https://api.dns-platform.jp/dpf/v1"
 
+const (
+	DefaultRateLimit rate.Limit = 1.0
+	DefaultRateBurst int        = 5
+)
+
 type ClientInterface interface {
 	SetRoundTripper(rt http.RoundTripper)
 	Read(ctx context.Context, s Spec) (string, error)
@@ -38,9 +44,31 @@ type Client struct {
 	Endpoint string
 	Token    string
 
-	logger Logger
-	client *http.Client
-
+	logger           Logger
+	loggerAdapter    *LoggerAdapter
+	structuredLogger StructuredLogger
+	client           *http.Client
+	retry            *RetryPolicy
+	hooks            []ResponseHook
+	middlewares      []Middleware
+
+	// number of workers for ListAll
+	listWorkers int
+
+	userAgent string
+	headers   http.Header
+
+	// used by New
+	httpClient   *http.Client
+	transport    http.RoundTripper
+	limiter      Limiter
+	rateObserver RateLimitObserver
+	timeout      time.Duration
+
+	// LastRequest and LastResponse are updated only when WithLastResponse option is set.
+	// These are not safe for concurrent use, use GetLastRequest/GetLastResponse or ResponseHook instead.
+	recordLast   bool
+	lastMu       sync.RWMutex
 	LastRequest  *RequestInfo
 	LastResponse *ResponseInfo
 }
@@ -56,9 +84,18 @@ type ResponseInfo struct {
 	Body     []byte
 }
 
+// RateLimitObserver is called with the time spent waiting for the client side rate limit.
+type RateLimitObserver func(ctx context.Context, wait time.Duration)
+
 type RateRoundTripper struct {
 	RroundTripper http.RoundTripper
 	Limiter       *rate.Limiter
+	// CustomLimiter is used instead of Limiter, if it is not nil (e.g. ratelimit.FileLimiter).
+	CustomLimiter Limiter
+	// Observer is called after waiting for Limiter, if it is not nil.
+	Observer RateLimitObserver
+
+	once sync.Once
 }
 
 func NewRateRoundTripper(rt http.RoundTripper, limiter *rate.Limiter) *RateRoundTripper {
@@ -68,33 +105,121 @@ func NewRateRoundTripper(rt http.RoundTripper, limiter *rate.Limiter) *RateRound
 	}
 }
 
-func (r *RateRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	if r.Limiter == nil {
-		r.Limiter = rate.NewLimiter(rate.Limit(1.0), 5)
-	}
-	if r.RroundTripper == nil {
-		r.RroundTripper = http.DefaultTransport
+// NewCustomRateRoundTripper returns RateRoundTripper which uses limiter as CustomLimiter.
+func NewCustomRateRoundTripper(rt http.RoundTripper, limiter Limiter) *RateRoundTripper {
+	return &RateRoundTripper{
+		RroundTripper: rt,
+		CustomLimiter: limiter,
 	}
-	if err := r.Limiter.Wait(req.Context()); err != nil {
-		return nil, fmt.Errorf("request rate-limit by client side: %w", err)
+}
+
+func (r *RateRoundTripper) limiter() Limiter {
+	if r.CustomLimiter != nil {
+		return r.CustomLimiter
 	}
-	return r.RroundTripper.RoundTrip(req)
+	return r.Limiter
 }
 
-func NewClient(token string, endpoint string, logger Logger) *Client {
-	if endpoint == "" {
-		endpoint = DefaultEndpoint
+func (r *RateRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.once.Do(func() {
+		if r.Limiter == nil && r.CustomLimiter == nil {
+			r.Limiter = rate.NewLimiter(DefaultRateLimit, DefaultRateBurst)
+		}
+		if r.RroundTripper == nil {
+			r.RroundTripper = http.DefaultTransport
+		}
+	})
+	limiter := r.limiter()
+	start := time.Now()
+	if err := limiter.Wait(req.Context()); err != nil {
+		return nil, fmt.Errorf("request rate-limit by client side: %w", err)
+	}
+	if r.Observer != nil {
+		r.Observer(req.Context(), time.Since(start))
 	}
-	if logger == nil {
-		logger = NewStdLogger(os.Stderr, "dpf-client", 0, 4)
+	resp, err := r.RroundTripper.RoundTrip(req)
+	if o, ok := limiter.(ResponseObserver); ok && err == nil {
+		o.ObserveResponse(resp)
 	}
-	return &Client{Endpoint: endpoint, Token: token, logger: logger, client: &http.Client{Transport: NewRateRoundTripper(nil, nil)}}
+	return resp, err
+}
+
+// NewClient returns Client.
+// It is thin wrapper of New.
+func NewClient(token string, endpoint string, logger Logger, opts ...ClientOption) *Client {
+	return New(token, append([]ClientOption{WithEndpoint(endpoint), WithLogger(logger)}, opts...)...)
+}
+
+// New returns Client configured by opts.
+func New(token string, opts ...ClientOption) *Client {
+	c := &Client{
+		Endpoint: DefaultEndpoint,
+		Token:    token,
+		logger:   NewStdLogger(os.Stderr, "dpf-client", 0, 4),
+		limiter:  rate.NewLimiter(DefaultRateLimit, DefaultRateBurst),
+		headers:  http.Header{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.loggerAdapter = NewLoggerAdapter(c.logger)
+	hc := &http.Client{}
+	if c.httpClient != nil {
+		copyClient := *c.httpClient
+		hc = &copyClient
+	}
+	transport := c.transport
+	if transport == nil {
+		transport = hc.Transport
+	}
+	var rt *RateRoundTripper
+	if l, ok := c.limiter.(*rate.Limiter); ok {
+		rt = NewRateRoundTripper(transport, l)
+	} else {
+		rt = NewCustomRateRoundTripper(transport, c.limiter)
+	}
+	rt.Observer = c.rateObserver
+	hc.Transport = rt
+	if c.timeout > 0 {
+		hc.Timeout = c.timeout
+	}
+	c.client = hc
+	return c
 }
 
 func (c *Client) SetRoundTripper(rt http.RoundTripper) {
 	c.client.Transport = rt
 }
 
+// GetLastRequest returns last request info, when WithLastResponse option is set.
+func (c *Client) GetLastRequest() *RequestInfo {
+	c.lastMu.RLock()
+	defer c.lastMu.RUnlock()
+	return c.LastRequest
+}
+
+// GetLastResponse returns last response info, when WithLastResponse option is set.
+func (c *Client) GetLastResponse() *ResponseInfo {
+	c.lastMu.RLock()
+	defer c.lastMu.RUnlock()
+	return c.LastResponse
+}
+
+func (c *Client) callHooks(ctx context.Context, reqInfo *RequestInfo, respInfo *ResponseInfo) {
+	if c.recordLast {
+		c.lastMu.Lock()
+		c.LastRequest = reqInfo
+		c.LastResponse = respInfo
+		c.lastMu.Unlock()
+	}
+	for _, hook := range c.hooks {
+		hook(ctx, reqInfo, respInfo)
+	}
+	if hook := ResponseHookFromContext(ctx); hook != nil {
+		hook(ctx, reqInfo, respInfo)
+	}
+}
+
 func (c *Client) marshalJSON(action Action, body interface{}) ([]byte, error) {
 	var (
 		jsonBody []byte
@@ -116,77 +241,86 @@ func (c *Client) marshalJSON(action Action, body interface{}) ([]byte, error) {
 	return jsonBody, nil
 }
 
-func (c *Client) doSetup(ctx context.Context, spec Spec, action Action, body interface{}, params SearchParams) (*http.Request, error) {
+func (c *Client) doSetup(ctx context.Context, spec Spec, action Action, body interface{}, params SearchParams) (*http.Request, *RequestInfo, error) {
 	var r io.Reader
 	if action == ActionCount {
 		_, ok := spec.(CountableListSpec)
 		if !ok {
-			return nil, fmt.Errorf("spec is not CountableListSpec")
+			return nil, nil, fmt.Errorf("spec is not CountableListSpec")
 		}
 	}
-	c.LastRequest = &RequestInfo{}
-	c.LastResponse = nil
+	reqInfo := &RequestInfo{}
 	// create URL
 	method, path := spec.GetPathMethod(action)
 	if path == "" {
-		return nil, fmt.Errorf("not support action %s", action)
+		return nil, nil, fmt.Errorf("not support action %s", action)
 	}
-	c.LastRequest.Method = method
+	reqInfo.Method = method
 	url := c.Endpoint + path
 	if params != nil {
 		p, err := params.GetValues()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get search params: %w", err)
+			return nil, nil, fmt.Errorf("failed to get search params: %w", err)
 		}
 		url += "?" + p.Encode()
 	}
-	c.LastRequest.URL = url
-	c.logger.Debugf("method: %s request-url: %s", method, url)
+	reqInfo.URL = url
+	c.log(ctx, LogLevelDebug, "request", Field("method", method), Field("url", url))
 	// make request body
 	if body != nil {
 		jsonBody, err := c.marshalJSON(action, body)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		c.logger.Tracef("request-body: `%s`", string(jsonBody))
-		c.LastRequest.Body = jsonBody
+		c.log(ctx, LogLevelTrace, "request body", Field("method", method), Field("url", url), Field("body", jsonBody))
+		reqInfo.Body = jsonBody
 		r = bytes.NewBuffer(jsonBody)
 	}
 
 	// make request
 	req, err := http.NewRequest(method, url, r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create http request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create http request: %w", err)
 	}
 	// authorized
 	req.Header.Add("Authorization", "Bearer "+c.Token)
 	req.Header.Add("Content-Type", "application/json")
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 
-	return req.WithContext(ctx), nil
+	return req.WithContext(ctx), reqInfo, nil
 }
 
 func (c *Client) Do(ctx context.Context, spec Spec, action Action, body interface{}, params SearchParams) (string, error) {
-	req, err := c.doSetup(ctx, spec, action, body, params)
+	call := &Call{Spec: spec, Action: action, Body: body, Params: params}
+	if len(c.middlewares) == 0 {
+		return c.invoke(ctx, call)
+	}
+	return chain(c.invoke, c.middlewares)(ctx, call)
+}
+
+func (c *Client) invoke(ctx context.Context, call *Call) (string, error) {
+	spec, action := call.Spec, call.Action
+	req, reqInfo, err := c.doSetup(ctx, spec, action, call.Body, call.Params)
 	if err != nil {
 		return "", err
 	}
 	// request
-	resp, err := c.client.Do(req)
+	resp, bs, err := c.doRequest(ctx, action, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to get http response: %w", err)
+		c.callHooks(ctx, reqInfo, nil)
+		return "", err
 	}
-	defer resp.Body.Close()
-	c.LastResponse = &ResponseInfo{
+	c.callHooks(ctx, reqInfo, &ResponseInfo{
 		Response: resp,
-	}
-	// get body
-	bs, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to get http response body: %w", err)
-	}
-	c.LastResponse.Body = bs
-	c.logger.Debugf("status-code: `%d`", resp.StatusCode)
-	c.logger.Tracef("response-body: `%s`", string(bs))
+		Body:     bs,
+	})
 
 	// if statiscode is error, response body type is BadResponse or Plantext
 	if resp.StatusCode >= http.StatusBadRequest {
@@ -217,6 +351,68 @@ func (c *Client) Do(ctx context.Context, spec Spec, action Action, body interfac
 	return rawResponse.RequestID, nil
 }
 
+func (c *Client) doRequest(ctx context.Context, action Action, req *http.Request) (*http.Response, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 {
+			var err error
+			if r, err = cloneRequest(ctx, req); err != nil {
+				return nil, nil, err
+			}
+		}
+		start := time.Now()
+		resp, err := c.client.Do(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get http response: %w", err)
+		}
+		// get body
+		bs, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get http response body: %w", err)
+		}
+		if c.logEnabled(ctx, LogLevelDebug) {
+			// request_id is parsed only for logging, the body is parsed again by caller.
+			common := &ResponseCommon{}
+			_ = UnmarshalRead(bs, common)
+			c.log(ctx, LogLevelDebug, "response",
+				Field("method", req.Method),
+				Field("url", req.URL.String()),
+				Field("status", resp.StatusCode),
+				Field("request_id", common.RequestID),
+				Field("duration", time.Since(start)),
+				Field("attempt", attempt),
+			)
+			c.log(ctx, LogLevelTrace, "response body", Field("request_id", common.RequestID), Field("body", bs))
+		}
+
+		if c.retry == nil || !c.retry.ShouldRetry(action, resp.StatusCode, attempt) {
+			return resp, bs, nil
+		}
+		wait := c.retry.Backoff(attempt, ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+		c.log(ctx, LogLevelDebug, "retry request", Field("status", resp.StatusCode), Field("attempt", attempt), Field("wait", wait))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, fmt.Errorf("failed to retry request: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func cloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request body for retry: %w", err)
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
 func (c *Client) doReadResponse(action Action, spec Spec, bs []byte, rawResponse *RawResponse) error {
 	switch {
 	case action == ActionCount:
@@ -260,30 +456,21 @@ func (c *Client) List(ctx context.Context, s ListSpec, keywords SearchParams) (s
 }
 
 func (c *Client) ListAll(ctx context.Context, s CountableListSpec, keywords SearchParams) (string, error) {
-	req, err := c.Count(ctx, s, keywords)
-	if err != nil {
-		return req, err
-	}
-
-	if keywords == nil {
-		keywords = &CommonSearchParams{}
-		keywords.SetLimit(s.GetMaxLimit())
-	}
-
-	count := s.GetCount()
-	cList := DeepCopyCountableListSpec(s)
-
-	for offset := int32(0); offset < count; offset += keywords.GetLimit() {
-		keywords.SetOffset(offset)
-		req, err = c.List(ctx, cList, keywords)
-		if err != nil {
-			return req, err
-		}
-		for i := 0; i < cList.Len(); i++ {
-			s.AddItem(cList.Index(i))
+	if c.listWorkers > 1 {
+		return ListAllConcurrent(ctx, c, s, keywords, c.listWorkers)
+	}
+	p := Paginate(ctx, c, s, keywords)
+	for p.Next() {
+		page := p.Page()
+		for i := 0; i < page.Len(); i++ {
+			s.AddItem(page.Index(i))
 		}
 	}
-	return req, nil
+	if err := p.Err(); err != nil {
+		return p.RequestID(), err
+	}
+	s.SetCount(p.Total())
+	return p.RequestID(), nil
 }
 
 func (c *Client) Count(ctx context.Context, s CountableListSpec, keywords SearchParams) (string, error) {
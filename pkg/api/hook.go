@@ -0,0 +1,22 @@
+package api
+
+import "context"
+
+// ResponseHook is called after each request of Client.Do.
+// respInfo is nil, when the client failed to get response.
+// Hook must be safe for concurrent use, when the client is shared by goroutines.
+type ResponseHook func(ctx context.Context, reqInfo *RequestInfo, respInfo *ResponseInfo)
+
+type responseHookKey struct{}
+
+// ContextWithResponseHook returns ctx which has per-call ResponseHook.
+func ContextWithResponseHook(ctx context.Context, hook ResponseHook) context.Context {
+	return context.WithValue(ctx, responseHookKey{}, hook)
+}
+
+// ResponseHookFromContext returns per-call ResponseHook of ctx.
+// It is used to chain hooks, returns nil if ctx doesn't have hook.
+func ResponseHookFromContext(ctx context.Context) ResponseHook {
+	hook, _ := ctx.Value(responseHookKey{}).(ResponseHook)
+	return hook
+}
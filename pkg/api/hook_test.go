@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("hook", func() {
+	var (
+		srv *testSpecServer
+		c   *api.Client
+		err error
+	)
+	BeforeEach(func() {
+		srv = newTestSpecServer(3)
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	Context("WithResponseHook", func() {
+		var (
+			reqInfos  []*api.RequestInfo
+			respInfos []*api.ResponseInfo
+		)
+		BeforeEach(func() {
+			reqInfos = nil
+			respInfos = nil
+			c = srv.NewClient(api.WithResponseHook(func(ctx context.Context, reqInfo *api.RequestInfo, respInfo *api.ResponseInfo) {
+				reqInfos = append(reqInfos, reqInfo)
+				respInfos = append(respInfos, respInfo)
+			}))
+			_, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+		})
+		It("calls hook", func() {
+			Expect(err).To(Succeed())
+			Expect(reqInfos).To(HaveLen(1))
+			Expect(reqInfos[0].Method).To(Equal(http.MethodGet))
+			Expect(reqInfos[0].URL).To(Equal(srv.URL + "/tests/id1"))
+			Expect(respInfos[0].Response.StatusCode).To(Equal(http.StatusOK))
+			Expect(string(respInfos[0].Body)).To(MatchRegexp(`"id":"id1"`))
+		})
+		It("does not update LastRequest and LastResponse", func() {
+			Expect(c.LastRequest).To(BeNil())
+			Expect(c.LastResponse).To(BeNil())
+		})
+	})
+	Context("ContextWithResponseHook", func() {
+		var respInfo *api.ResponseInfo
+		BeforeEach(func() {
+			c = srv.NewClient()
+			ctx := api.ContextWithResponseHook(context.Background(), func(ctx context.Context, _ *api.RequestInfo, r *api.ResponseInfo) {
+				respInfo = r
+			})
+			_, err = c.Read(ctx, &TestSpec{ID: "not-found"})
+		})
+		It("calls per-call hook", func() {
+			Expect(api.IsNotFound(err)).To(BeTrue())
+			Expect(respInfo).NotTo(BeNil())
+			Expect(respInfo.Response.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+	Context("WithLastResponse", func() {
+		BeforeEach(func() {
+			c = srv.NewClient(api.WithLastResponse())
+			_, err = c.Read(context.Background(), &TestSpec{ID: "id2"})
+		})
+		It("updates LastRequest and LastResponse", func() {
+			Expect(err).To(Succeed())
+			Expect(c.GetLastRequest()).NotTo(BeNil())
+			Expect(c.GetLastRequest().URL).To(Equal(srv.URL + "/tests/id2"))
+			Expect(c.GetLastResponse()).NotTo(BeNil())
+			Expect(c.GetLastResponse().Response.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+	Context("concurrent use", func() {
+		var (
+			mu    sync.Mutex
+			count int
+			errs  []error
+		)
+		BeforeEach(func() {
+			count = 0
+			errs = nil
+			c = srv.NewClient(api.WithLastResponse(), api.WithResponseHook(func(ctx context.Context, _ *api.RequestInfo, _ *api.ResponseInfo) {
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}))
+			wg := &sync.WaitGroup{}
+			for i := 0; i < 10; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					_, err := c.Read(context.Background(), &TestSpec{ID: "id0"})
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}()
+				go func() {
+					defer wg.Done()
+					_, err := c.ListAll(context.Background(), &TestSpecCountableList{}, &api.CommonSearchParams{Limit: 1})
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+		})
+		It("can share client by goroutines", func() {
+			for _, err := range errs {
+				Expect(err).To(Succeed())
+			}
+			// Read: 1 request, ListAll: count + 3 list requests
+			Expect(count).To(Equal(10 * (1 + 4)))
+		})
+	})
+})
@@ -2,9 +2,11 @@ package api
 
 import (
 	"reflect"
+	"unsafe"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/mimuret/golang-iij-dpf/pkg/meta"
+	"github.com/modern-go/reflect2"
 )
 
 var JSON = NewJSONAPIAdapter()
@@ -45,6 +47,43 @@ type JSONFileInterface interface {
 	UnMarshalInput(bs []byte, obj Object) error
 }
 
+// InputUnmarshaler is implemented by types whose file format differs from api response.
+// UnMarshalInput uses UnmarshalInput instead of UnmarshalJSON, which is used for api response.
+type InputUnmarshaler interface {
+	UnmarshalInput(bs []byte) error
+}
+
+var inputUnmarshalerType = reflect.TypeOf((*InputUnmarshaler)(nil)).Elem()
+
+// inputExtension decodes InputUnmarshaler by UnmarshalInput.
+type inputExtension struct {
+	jsoniter.DummyExtension
+}
+
+func (e *inputExtension) CreateDecoder(typ reflect2.Type) jsoniter.ValDecoder {
+	if typ.Kind() == reflect.Ptr || !reflect.PtrTo(typ.Type1()).Implements(inputUnmarshalerType) {
+		return nil
+	}
+	return &inputDecoder{typ: typ}
+}
+
+type inputDecoder struct {
+	typ reflect2.Type
+}
+
+func (d *inputDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	if iter.ReadNil() {
+		return
+	}
+	bs := iter.SkipAndReturnBytes()
+	if iter.Error != nil {
+		return
+	}
+	if err := d.typ.PackEFace(ptr).(InputUnmarshaler).UnmarshalInput(bs); err != nil {
+		iter.ReportError("UnmarshalInput", err.Error())
+	}
+}
+
 type JSONAPIAdapter struct {
 	Read   jsoniter.API
 	Update jsoniter.API
@@ -54,7 +93,7 @@ type JSONAPIAdapter struct {
 }
 
 func NewJSONAPIAdapter() *JSONAPIAdapter {
-	return &JSONAPIAdapter{
+	j := &JSONAPIAdapter{
 		Read: jsoniter.Config{
 			EscapeHTML:             true,
 			SortMapKeys:            false,
@@ -91,6 +130,8 @@ func NewJSONAPIAdapter() *JSONAPIAdapter {
 			TagKey:                 "json",
 		}.Froze(),
 	}
+	j.JSON.RegisterExtension(&inputExtension{})
+	return j
 }
 
 // Unmarshal api response.
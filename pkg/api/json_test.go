@@ -1,6 +1,8 @@
 package api_test
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -20,6 +22,41 @@ func (JsonTest) GetPathMethod(a api.Action) (string, string) {
 	return a.ToMethod(), "/tests/jsontests"
 }
 
+var _ api.Spec = &JsonInputTest{}
+
+type JsonInputTest struct {
+	Item  InputTest   `json:"item"`
+	Items []InputTest `json:"items"`
+}
+
+func (j *JsonInputTest) DeepCopyObject() api.Object { return &JsonInputTest{} }
+func (JsonInputTest) GetName() string               { return "jsoninputtests" }
+func (JsonInputTest) GetGroup() string              { return "tests" }
+func (JsonInputTest) GetPathMethod(a api.Action) (string, string) {
+	return a.ToMethod(), "/tests/jsoninputtests"
+}
+
+var _ api.InputUnmarshaler = &InputTest{}
+
+type InputTest struct {
+	Name string
+}
+
+func (i *InputTest) UnmarshalJSON(bs []byte) error {
+	return fmt.Errorf("UnmarshalJSON is called")
+}
+
+func (i *InputTest) UnmarshalInput(bs []byte) error {
+	c := struct {
+		Name string `json:"name"`
+	}{}
+	if err := api.JSON.JSON.Unmarshal(bs, &c); err != nil {
+		return err
+	}
+	i.Name = c.Name
+	return nil
+}
+
 var _ = Describe("json", func() {
 	var (
 		bs    []byte
@@ -39,6 +76,29 @@ var _ = Describe("json", func() {
 				Expect(value.Name).To(Equal("book"))
 			})
 		})
+		Context("UnMarshalInput(InputUnmarshaler)", func() {
+			var input JsonInputTest
+			BeforeEach(func() {
+				input = JsonInputTest{}
+			})
+			When("value is valid", func() {
+				BeforeEach(func() {
+					err = api.UnMarshalInput([]byte(`{"kind": "JsonInputTest", "apiVersion": "tests", "resource": {"item": {"name": "book"}, "items": [{"name": "pen"}, null]}}`), &input)
+				})
+				It("uses UnmarshalInput instead of UnmarshalJSON", func() {
+					Expect(err).To(Succeed())
+					Expect(input).To(Equal(JsonInputTest{Item: InputTest{Name: "book"}, Items: []InputTest{{Name: "pen"}, {}}}))
+				})
+			})
+			When("UnmarshalInput returns error", func() {
+				BeforeEach(func() {
+					err = api.UnMarshalInput([]byte(`{"kind": "JsonInputTest", "apiVersion": "tests", "resource": {"item": {"name": 1}}}`), &input)
+				})
+				It("returns error", func() {
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
 		Context("MarshalCreate(tag name is `create`)", func() {
 			BeforeEach(func() {
 				bs, err = api.MarshalCreate(&value)
@@ -86,5 +146,28 @@ var _ = Describe("json", func() {
 				Expect(value.Name).To(Equal("book"))
 			})
 		})
+		Context("UnMarshalInput(InputUnmarshaler)", func() {
+			var input JsonInputTest
+			BeforeEach(func() {
+				input = JsonInputTest{}
+			})
+			When("value is valid", func() {
+				BeforeEach(func() {
+					err = api.UnMarshalInput([]byte(`{"kind": "JsonInputTest", "apiVersion": "tests", "resource": {"item": {"name": "book"}, "items": [{"name": "pen"}, null]}}`), &input)
+				})
+				It("uses UnmarshalInput instead of UnmarshalJSON", func() {
+					Expect(err).To(Succeed())
+					Expect(input).To(Equal(JsonInputTest{Item: InputTest{Name: "book"}, Items: []InputTest{{Name: "pen"}, {}}}))
+				})
+			})
+			When("UnmarshalInput returns error", func() {
+				BeforeEach(func() {
+					err = api.UnMarshalInput([]byte(`{"kind": "JsonInputTest", "apiVersion": "tests", "resource": {"item": {"name": 1}}}`), &input)
+				})
+				It("returns error", func() {
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
 	})
 })
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter is client side rate limiter of RateRoundTripper.
+// Wait blocks until the request is allowed.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+var _ Limiter = &rate.Limiter{}
+
+// ResponseObserver is optional interface of Limiter.
+// If Limiter implements it, RateRoundTripper calls ObserveResponse with each response.
+// It is used to slow down when the server returns TooManyRequests.
+type ResponseObserver interface {
+	ObserveResponse(resp *http.Response)
+}
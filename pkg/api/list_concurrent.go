@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// ListAllConcurrent is same as ListAll, but it fetches pages by workers concurrently.
+// Requests are still limited by the rate limiter of the client.
+// Items are added into s in offset order.
+func ListAllConcurrent(ctx context.Context, cl ClientInterface, s CountableListSpec, keywords SearchParams, workers int) (string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	reqID, err := cl.Count(ctx, s, keywords)
+	if err != nil {
+		return reqID, err
+	}
+	limit := s.GetMaxLimit()
+	base := url.Values{}
+	if keywords != nil {
+		limit = keywords.GetLimit()
+		values, err := keywords.GetValues()
+		if err != nil {
+			return reqID, fmt.Errorf("failed to get search params: %w", err)
+		}
+		base = values
+	}
+	if limit <= 0 {
+		return reqID, fmt.Errorf("limit must be greater than 0")
+	}
+	count := s.GetCount()
+	pages := int((count + limit - 1) / limit)
+	if pages == 0 {
+		return reqID, nil
+	}
+
+	type result struct {
+		spec  CountableListSpec
+		reqID string
+	}
+	results := make([]result, pages)
+	var (
+		mu       sync.Mutex
+		errReqID string
+		firstErr error
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pageCh := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers && i < pages; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range pageCh {
+				params := &RowSearchParams{Values: copyValues(base)}
+				params.SetOffset(int32(page) * limit)
+				params.SetLimit(limit)
+				cList := DeepCopyCountableListSpec(s)
+				cList.ClearItems()
+				reqID, err := cl.List(ctx, cList, params)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						errReqID, firstErr = reqID, err
+					}
+					mu.Unlock()
+					cancel()
+					continue
+				}
+				results[page] = result{spec: cList, reqID: reqID}
+			}
+		}()
+	}
+SEND:
+	for page := 0; page < pages; page++ {
+		select {
+		case pageCh <- page:
+		case <-ctx.Done():
+			break SEND
+		}
+	}
+	close(pageCh)
+	wg.Wait()
+
+	if firstErr != nil {
+		return errReqID, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return reqID, err
+	}
+	for _, res := range results {
+		for i := 0; i < res.spec.Len(); i++ {
+			s.AddItem(res.spec.Index(i))
+		}
+		reqID = res.reqID
+	}
+	return reqID, nil
+}
+
+func copyValues(values url.Values) url.Values {
+	res := url.Values{}
+	for key, value := range values {
+		res[key] = append([]string{}, value...)
+	}
+	return res
+}
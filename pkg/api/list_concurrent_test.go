@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("ListAllConcurrent", func() {
+	var (
+		srv   *testSpecServer
+		c     *api.Client
+		spec  *TestSpecCountableList
+		reqId string
+		err   error
+	)
+	BeforeEach(func() {
+		srv = newTestSpecServer(25)
+		c = srv.NewClient()
+		spec = &TestSpecCountableList{}
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	When("fetches pages concurrently", func() {
+		BeforeEach(func() {
+			reqId, err = api.ListAllConcurrent(context.Background(), c, spec, &api.CommonSearchParams{Limit: 3}, 4)
+		})
+		It("returns items in offset order", func() {
+			Expect(err).To(Succeed())
+			Expect(reqId).NotTo(BeEmpty())
+			Expect(spec.GetCount()).To(Equal(int32(25)))
+			Expect(spec.Items).To(HaveLen(25))
+			sequential := &TestSpecCountableList{}
+			_, err = c.ListAll(context.Background(), sequential, &api.CommonSearchParams{Limit: 3})
+			Expect(err).To(Succeed())
+			Expect(spec.Items).To(Equal(sequential.Items))
+		})
+		It("requests count and all pages", func() {
+			Expect(srv.Calls()).To(Equal(1 + 9))
+		})
+	})
+	When("keywords is nil", func() {
+		BeforeEach(func() {
+			reqId, err = api.ListAllConcurrent(context.Background(), c, spec, nil, 4)
+		})
+		It("uses max limit", func() {
+			Expect(err).To(Succeed())
+			Expect(spec.Items).To(HaveLen(25))
+			Expect(srv.Calls()).To(Equal(2))
+		})
+	})
+	When("no items", func() {
+		BeforeEach(func() {
+			srv.SetItems(0)
+			reqId, err = api.ListAllConcurrent(context.Background(), c, spec, &api.CommonSearchParams{Limit: 3}, 4)
+		})
+		It("returns empty list", func() {
+			Expect(err).To(Succeed())
+			Expect(spec.Items).To(BeEmpty())
+		})
+	})
+	When("a page request failed", func() {
+		BeforeEach(func() {
+			srv.SetFailOffset(6)
+			reqId, err = api.ListAllConcurrent(context.Background(), c, spec, &api.CommonSearchParams{Limit: 3}, 4)
+		})
+		It("returns the error", func() {
+			Expect(api.IsSystemError(err)).To(BeTrue())
+			Expect(spec.Items).To(BeEmpty())
+		})
+	})
+	When("client has rate limit", func() {
+		var start time.Time
+		BeforeEach(func() {
+			c = api.New("token", api.WithEndpoint(srv.URL), api.WithTransport(srv.Client().Transport), api.WithRateLimit(rate.Every(50*time.Millisecond), 1))
+			start = time.Now()
+			_, err = api.ListAllConcurrent(context.Background(), c, spec, &api.CommonSearchParams{Limit: 5}, 5)
+		})
+		It("honors rate limit", func() {
+			Expect(err).To(Succeed())
+			Expect(spec.Items).To(HaveLen(25))
+			// count + 5 pages
+			Expect(time.Since(start)).To(BeNumerically(">=", 250*time.Millisecond))
+		})
+	})
+	When("WithListConcurrency is set", func() {
+		BeforeEach(func() {
+			c = srv.NewClient(api.WithListConcurrency(3))
+			_, err = c.ListAll(context.Background(), spec, &api.CommonSearchParams{Limit: 2})
+		})
+		It("ListAll fetches pages concurrently", func() {
+			Expect(err).To(Succeed())
+			Expect(spec.Items).To(HaveLen(25))
+			Expect(spec.Items[24].ID).To(Equal("id24"))
+		})
+	})
+})
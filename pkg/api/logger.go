@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"io"
 	"log"
 	"os"
@@ -14,7 +15,10 @@ type Logger interface {
 	Errorf(format string, args ...interface{})
 }
 
-var _ Logger = &StdLogger{}
+var (
+	_ Logger       = &StdLogger{}
+	_ LevelEnabler = &StdLogger{}
+)
 
 // simple logger
 // change better logger as you like.
@@ -36,6 +40,11 @@ func NewStdLogger(out io.Writer, prefix string, flag int, level int) *StdLogger
 	}
 }
 
+// Enabled returns true, if level is greater than or equal to LogLevel.
+func (s *StdLogger) Enabled(_ context.Context, level LogLevel) bool {
+	return int(level) >= s.LogLevel
+}
+
 func (s *StdLogger) Tracef(format string, args ...interface{}) {
 	if s.LogLevel <= 0 {
 		s.Printf(format, args...)
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Call is arguments of Client.Do.
+type Call struct {
+	Spec   Spec
+	Action Action
+	Body   interface{}
+	Params SearchParams
+}
+
+// Invoker executes the call and returns request id.
+type Invoker func(ctx context.Context, call *Call) (requestID string, err error)
+
+// Middleware wraps Invoker of Client.Do.
+// Middleware can inspect or modify the call before next, and the request id and error after next.
+// If Middleware returns without calling next, the request is not sent.
+// Middleware must be safe for concurrent use, when the client is shared by goroutines.
+type Middleware func(next Invoker) Invoker
+
+// ErrDryRun is returned by DryRunMiddleware for mutating actions.
+var ErrDryRun = errors.New("dry-run mode, mutating action is not executed")
+
+// DryRunMiddleware blocks mutating actions and returns ErrDryRun.
+func DryRunMiddleware() Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (string, error) {
+			if call.Action.IsMutating() {
+				return "", fmt.Errorf("%s %s: %w", call.Action, call.Spec.GetName(), ErrDryRun)
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// PolicyMiddleware calls policy before the request.
+// If policy returns error, the request is not sent and the error is returned.
+func PolicyMiddleware(policy func(ctx context.Context, call *Call) error) Middleware {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (string, error) {
+			if err := policy(ctx, call); err != nil {
+				return "", fmt.Errorf("denied by policy: %w", err)
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// chain returns Invoker which calls middlewares in order, and invoker at last.
+func chain(invoker Invoker, middlewares []Middleware) Invoker {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		invoker = middlewares[i](invoker)
+	}
+	return invoker
+}
@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("middleware", func() {
+	var (
+		srv   *testSpecServer
+		c     *api.Client
+		reqID string
+		err   error
+	)
+	BeforeEach(func() {
+		srv = newTestSpecServer(3)
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	Context("WithMiddleware", func() {
+		var (
+			order  []string
+			calls  []*api.Call
+			reqIDs []string
+		)
+		record := func(name string) api.Middleware {
+			return func(next api.Invoker) api.Invoker {
+				return func(ctx context.Context, call *api.Call) (string, error) {
+					order = append(order, name+"-before")
+					id, err := next(ctx, call)
+					order = append(order, name+"-after")
+					calls = append(calls, call)
+					reqIDs = append(reqIDs, id)
+					return id, err
+				}
+			}
+		}
+		BeforeEach(func() {
+			order, calls, reqIDs = nil, nil, nil
+			c = srv.NewClient(api.WithMiddleware(record("first"), record("second")))
+			reqID, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+		})
+		It("calls middlewares in order", func() {
+			Expect(err).To(Succeed())
+			Expect(order).To(Equal([]string{"first-before", "second-before", "second-after", "first-after"}))
+		})
+		It("passes call and request id", func() {
+			Expect(calls[0].Action).To(Equal(api.ActionRead))
+			Expect(calls[0].Spec).To(Equal(&TestSpec{ID: "id1", Name: "test1", Number: 1}))
+			Expect(reqIDs[0]).To(Equal(reqID))
+			Expect(reqID).To(Equal("REQ1"))
+		})
+	})
+	Context("modify call", func() {
+		BeforeEach(func() {
+			c = srv.NewClient(api.WithMiddleware(func(next api.Invoker) api.Invoker {
+				return func(ctx context.Context, call *api.Call) (string, error) {
+					call.Spec = &TestSpec{ID: "id2"}
+					return next(ctx, call)
+				}
+			}))
+			_, err = c.Read(context.Background(), &TestSpec{ID: "not-found"})
+		})
+		It("requests modified spec", func() {
+			Expect(err).To(Succeed())
+			Expect(srv.Calls()).To(Equal(1))
+		})
+	})
+	Context("DryRunMiddleware", func() {
+		BeforeEach(func() {
+			c = srv.NewClient(api.WithMiddleware(api.DryRunMiddleware()))
+		})
+		It("blocks mutating action", func() {
+			_, err = c.Update(context.Background(), &TestSpec{ID: "id1"}, nil)
+			Expect(errors.Is(err, api.ErrDryRun)).To(BeTrue())
+			Expect(srv.Calls()).To(BeZero())
+		})
+		It("does not block read action", func() {
+			_, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+			Expect(err).To(Succeed())
+			Expect(srv.Calls()).To(Equal(1))
+		})
+	})
+	Context("PolicyMiddleware", func() {
+		errForbidden := errors.New("delete is forbidden")
+		BeforeEach(func() {
+			c = srv.NewClient(api.WithMiddleware(api.PolicyMiddleware(func(ctx context.Context, call *api.Call) error {
+				if call.Action == api.ActionDelete {
+					return errForbidden
+				}
+				return nil
+			})))
+		})
+		It("returns policy error", func() {
+			_, err = c.Delete(context.Background(), &TestSpec{ID: "id1"})
+			Expect(errors.Is(err, errForbidden)).To(BeTrue())
+			Expect(srv.Calls()).To(BeZero())
+		})
+		It("sends allowed request", func() {
+			_, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+			Expect(err).To(Succeed())
+			Expect(srv.Calls()).To(Equal(1))
+		})
+	})
+})
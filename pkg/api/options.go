@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type ClientOption func(*Client)
+
+// WithEndpoint sets API endpoint.
+// If endpoint is empty, DefaultEndpoint is used.
+func WithEndpoint(endpoint string) ClientOption {
+	return func(c *Client) {
+		if endpoint != "" {
+			c.Endpoint = endpoint
+		}
+	}
+}
+
+// WithLogger sets logger.
+// If logger is nil, StdLogger is used.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithStructuredLogger sets structured logger.
+// If it is set, it is used instead of Logger for request and response logs.
+// The token and secret attributes are redacted before logging.
+func WithStructuredLogger(logger StructuredLogger) ClientOption {
+	return func(c *Client) {
+		c.structuredLogger = logger
+	}
+}
+
+// WithHTTPClient sets base http.Client.
+// The transport of hc is wrapped by RateRoundTripper.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport sets base http.RoundTripper.
+// rt is wrapped by RateRoundTripper.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// WithRateLimit sets client side rate limit.
+// If limit is rate.Inf, rate limit is disabled.
+func WithRateLimit(limit rate.Limit, burst int) ClientOption {
+	return func(c *Client) {
+		c.limiter = rate.NewLimiter(limit, burst)
+	}
+}
+
+// WithLimiter sets client side rate limiter.
+// It is used instead of the limiter of WithRateLimit.
+func WithLimiter(limiter Limiter) ClientOption {
+	return func(c *Client) {
+		// typed nil *rate.Limiter is also ignored.
+		if l, ok := limiter.(*rate.Limiter); limiter == nil || (ok && l == nil) {
+			return
+		}
+		c.limiter = limiter
+	}
+}
+
+// WithRateLimitObserver sets observer of the time spent waiting for the client side rate limit.
+func WithRateLimitObserver(observer RateLimitObserver) ClientOption {
+	return func(c *Client) {
+		c.rateObserver = observer
+	}
+}
+
+// WithTimeout sets timeout of each http request.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithUserAgent sets User-Agent header.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeaders adds http headers to each request.
+func WithHeaders(headers http.Header) ClientOption {
+	return func(c *Client) {
+		for key, values := range headers {
+			for _, value := range values {
+				c.headers.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithRetry enables automatic retry of Client.Do.
+// If policy is nil, DefaultRetryPolicy is used.
+func WithRetry(policy *RetryPolicy) ClientOption {
+	return func(c *Client) {
+		if policy == nil {
+			policy = DefaultRetryPolicy()
+		}
+		c.retry = policy
+	}
+}
+
+// WithListConcurrency makes ListAll fetch pages by workers concurrently.
+func WithListConcurrency(workers int) ClientOption {
+	return func(c *Client) {
+		c.listWorkers = workers
+	}
+}
+
+// WithResponseHook adds hook which is called after each request.
+func WithResponseHook(hook ResponseHook) ClientOption {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+// WithMiddleware adds middlewares around Client.Do.
+// The first middleware is the outermost.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithLastResponse enables to update Client.LastRequest and Client.LastResponse.
+// It is compatibility option for old code.
+func WithLastResponse() ClientOption {
+	return func(c *Client) {
+		c.recordLast = true
+	}
+}
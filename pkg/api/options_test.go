@@ -0,0 +1,209 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+type countRoundTripper struct {
+	mu    sync.Mutex
+	count int
+	rt    http.RoundTripper
+}
+
+func (c *countRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+	return c.rt.RoundTrip(req)
+}
+
+type countLimiter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *countLimiter) Wait(_ context.Context) error {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+	return nil
+}
+
+var _ = Describe("options", func() {
+	var (
+		srv    *httptest.Server
+		header http.Header
+		sleep  time.Duration
+		c      *api.Client
+		reqId  string
+		err    error
+		spec   *TestSpec
+	)
+	BeforeEach(func() {
+		header = nil
+		sleep = 0
+		spec = &TestSpec{ID: "id1"}
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Clone()
+			time.Sleep(sleep)
+			_, _ = w.Write([]byte(`{"request_id": "OK", "result": {"id": "id1", "name": "test1", "number": 1}}`))
+		}))
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	Context("New", func() {
+		When("no options", func() {
+			BeforeEach(func() {
+				c = api.New("token")
+			})
+			It("uses DefaultEndpoint", func() {
+				Expect(c.Endpoint).To(Equal(api.DefaultEndpoint))
+				Expect(c.Token).To(Equal("token"))
+			})
+		})
+		When("WithEndpoint is empty", func() {
+			BeforeEach(func() {
+				c = api.New("token", api.WithEndpoint(""))
+			})
+			It("uses DefaultEndpoint", func() {
+				Expect(c.Endpoint).To(Equal(api.DefaultEndpoint))
+			})
+		})
+	})
+	Context("WithUserAgent and WithHeaders", func() {
+		BeforeEach(func() {
+			c = api.New("token",
+				api.WithEndpoint(srv.URL),
+				api.WithTransport(&http.Transport{}),
+				api.WithRateLimit(rate.Inf, 0),
+				api.WithUserAgent("dpf-test/1.0"),
+				api.WithHeaders(http.Header{"X-Test": []string{"hoge"}}),
+			)
+			reqId, err = c.Read(context.Background(), spec)
+		})
+		It("sends headers", func() {
+			Expect(err).To(Succeed())
+			Expect(reqId).To(Equal("OK"))
+			Expect(header.Get("User-Agent")).To(Equal("dpf-test/1.0"))
+			Expect(header.Get("X-Test")).To(Equal("hoge"))
+			Expect(header.Get("Authorization")).To(Equal("Bearer token"))
+		})
+	})
+	Context("WithTimeout", func() {
+		BeforeEach(func() {
+			sleep = 500 * time.Millisecond
+			c = api.New("token",
+				api.WithEndpoint(srv.URL),
+				api.WithTransport(&http.Transport{}),
+				api.WithTimeout(100*time.Millisecond),
+			)
+			_, err = c.Read(context.Background(), spec)
+		})
+		It("returns timeout error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("failed to get http response"))
+		})
+	})
+	Context("WithHTTPClient", func() {
+		var rt *countRoundTripper
+		BeforeEach(func() {
+			rt = &countRoundTripper{rt: &http.Transport{}}
+			hc := &http.Client{Transport: rt}
+			c = api.New("token", api.WithEndpoint(srv.URL), api.WithHTTPClient(hc))
+			_, err = c.Read(context.Background(), spec)
+		})
+		It("uses transport of http.Client", func() {
+			Expect(err).To(Succeed())
+			Expect(rt.count).To(Equal(1))
+		})
+	})
+	Context("WithRateLimit", func() {
+		var start time.Time
+		BeforeEach(func() {
+			c = api.New("token",
+				api.WithEndpoint(srv.URL),
+				api.WithTransport(&http.Transport{}),
+				api.WithRateLimit(rate.Every(200*time.Millisecond), 1),
+			)
+			start = time.Now()
+			for i := 0; i < 3; i++ {
+				_, err = c.Read(context.Background(), spec)
+				Expect(err).To(Succeed())
+			}
+		})
+		It("keeps rate limiter with custom transport", func() {
+			Expect(time.Since(start)).To(BeNumerically(">=", 350*time.Millisecond))
+		})
+	})
+	Context("WithLimiter", func() {
+		var start time.Time
+		BeforeEach(func() {
+			c = api.New("token",
+				api.WithEndpoint(srv.URL),
+				api.WithTransport(&http.Transport{}),
+				api.WithLimiter(rate.NewLimiter(rate.Every(200*time.Millisecond), 1)),
+			)
+			start = time.Now()
+			for i := 0; i < 3; i++ {
+				_, err = c.Read(context.Background(), spec)
+				Expect(err).To(Succeed())
+			}
+		})
+		It("uses the limiter", func() {
+			Expect(time.Since(start)).To(BeNumerically(">=", 350*time.Millisecond))
+		})
+	})
+	Context("WithLimiter typed nil", func() {
+		BeforeEach(func() {
+			var limiter *rate.Limiter
+			c = api.New("token",
+				api.WithEndpoint(srv.URL),
+				api.WithTransport(&http.Transport{}),
+				api.WithLimiter(limiter),
+			)
+			_, err = c.Read(context.Background(), spec)
+		})
+		It("uses the default limiter", func() {
+			Expect(err).To(Succeed())
+		})
+	})
+	Context("RateRoundTripper", func() {
+		It("uses the default limiter when Limiter is nil", func() {
+			c = api.New("token", api.WithEndpoint(srv.URL))
+			c.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, nil))
+			_, err = c.Read(context.Background(), spec)
+			Expect(err).To(Succeed())
+		})
+		It("uses CustomLimiter instead of Limiter", func() {
+			limiter := &countLimiter{}
+			c = api.New("token", api.WithEndpoint(srv.URL))
+			c.SetRoundTripper(api.NewCustomRateRoundTripper(&http.Transport{}, limiter))
+			_, err = c.Read(context.Background(), spec)
+			Expect(err).To(Succeed())
+			Expect(limiter.count).To(Equal(1))
+		})
+	})
+	Context("NewClient", func() {
+		BeforeEach(func() {
+			c = api.NewClient("token", srv.URL, nil, api.WithTransport(&http.Transport{}), api.WithUserAgent("dpf-test/2.0"))
+			_, err = c.Read(context.Background(), spec)
+		})
+		It("accepts options", func() {
+			Expect(err).To(Succeed())
+			Expect(c.Endpoint).To(Equal(srv.URL))
+			Expect(header.Get("User-Agent")).To(Equal("dpf-test/2.0"))
+		})
+	})
+})
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrStopIteration can be returned by ForEach callbacks to stop iteration without error.
+	ErrStopIteration = errors.New("stop iteration")
+	// ErrCountChanged is returned when WithStrictCount is set and the number of items is changed during pagination.
+	ErrCountChanged = errors.New("count changed during pagination")
+)
+
+type PaginateOption func(*Paginator)
+
+// WithPageProgress sets callback which is called after each page is fetched.
+func WithPageProgress(f func(fetched int32, total int32)) PaginateOption {
+	return func(p *Paginator) {
+		p.progress = f
+	}
+}
+
+// WithStrictCount makes Paginator return ErrCountChanged,
+// when the number of items is changed between Count and the last page.
+func WithStrictCount() PaginateOption {
+	return func(p *Paginator) {
+		p.strict = true
+	}
+}
+
+// Paginator fetches CountableListSpec page by page.
+// It is not safe for concurrent use.
+//
+//	p := api.Paginate(ctx, cl, &zones.RecordList{AttributeMeta: zones.AttributeMeta{ZoneID: id}}, nil)
+//	for p.Next() {
+//		page := p.Page().(*zones.RecordList)
+//		...
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Paginator struct {
+	ctx    context.Context
+	cl     ClientInterface
+	spec   CountableListSpec
+	params SearchParams
+	// params for Count, it may be nil
+	countParams SearchParams
+	progress    func(fetched int32, total int32)
+	strict      bool
+
+	started   bool
+	done      bool
+	total     int32
+	offset    int32
+	fetched   int32
+	page      CountableListSpec
+	requestID string
+	err       error
+}
+
+// Paginate returns Paginator for s.
+// s is used as template of each page, s itself is not modified.
+// If params is nil, it uses CommonSearchParams with s.GetMaxLimit().
+// params is copied, offset of the copy is overwritten by Paginator.
+func Paginate(ctx context.Context, cl ClientInterface, s CountableListSpec, params SearchParams, opts ...PaginateOption) *Paginator {
+	p := &Paginator{
+		ctx:         ctx,
+		cl:          cl,
+		spec:        s,
+		countParams: params,
+	}
+	if params == nil {
+		p.params = &CommonSearchParams{}
+		p.params.SetLimit(s.GetMaxLimit())
+	} else {
+		var err error
+		if p.params, err = copySearchParams(params); err != nil {
+			p.finish(err)
+		}
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Next fetches next page. It returns false when no more page or an error occurred.
+func (p *Paginator) Next() bool {
+	if p.done {
+		return false
+	}
+	if !p.started {
+		p.started = true
+		countSpec := DeepCopyCountableListSpec(p.spec)
+		reqID, err := p.cl.Count(p.ctx, countSpec, p.countParams)
+		p.requestID = reqID
+		if err != nil {
+			return p.finish(err)
+		}
+		p.total = countSpec.GetCount()
+	}
+	if p.offset >= p.total {
+		return p.finish(p.checkCount())
+	}
+	limit := p.params.GetLimit()
+	p.params.SetOffset(p.offset)
+	page := DeepCopyCountableListSpec(p.spec)
+	page.ClearItems()
+	reqID, err := p.cl.List(p.ctx, page, p.params)
+	p.requestID = reqID
+	if err != nil {
+		return p.finish(err)
+	}
+	if page.Len() == 0 {
+		// items are deleted during pagination
+		return p.finish(p.checkCount())
+	}
+	p.page = page
+	p.offset += limit
+	p.fetched += int32(page.Len())
+	if int32(page.Len()) < limit && p.offset < p.total {
+		// items are deleted during pagination, this is last page.
+		p.offset = p.total
+	}
+	if p.progress != nil {
+		p.progress(p.fetched, p.total)
+	}
+	return true
+}
+
+// copySearchParams returns a copy of params, SetOffset of the copy doesn't modify params.
+func copySearchParams(params SearchParams) (SearchParams, error) {
+	if r, ok := params.(*RowSearchParams); ok {
+		return &RowSearchParams{Values: copyValues(r.Values)}, nil
+	}
+	// keywords types (e.g. zones.KeywordsRecord) are pointer of struct.
+	v := reflect.ValueOf(params)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(v.Elem())
+		if res, ok := c.Interface().(SearchParams); ok {
+			return res, nil
+		}
+	}
+	values, err := params.GetValues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search params: %w", err)
+	}
+	res := &RowSearchParams{Values: copyValues(values)}
+	res.SetLimit(params.GetLimit())
+	return res, nil
+}
+
+func (p *Paginator) finish(err error) bool {
+	p.done = true
+	p.page = nil
+	p.err = err
+	return false
+}
+
+func (p *Paginator) checkCount() error {
+	if !p.strict {
+		return nil
+	}
+	if p.fetched != p.total {
+		return fmt.Errorf("%w: count %d, fetched %d", ErrCountChanged, p.total, p.fetched)
+	}
+	countSpec := DeepCopyCountableListSpec(p.spec)
+	reqID, err := p.cl.Count(p.ctx, countSpec, p.countParams)
+	p.requestID = reqID
+	if err != nil {
+		return err
+	}
+	if countSpec.GetCount() != p.total {
+		return fmt.Errorf("%w: count %d, current count %d", ErrCountChanged, p.total, countSpec.GetCount())
+	}
+	return nil
+}
+
+// Page returns current page.
+func (p *Paginator) Page() CountableListSpec { return p.page }
+
+// Err returns the error which stopped pagination.
+func (p *Paginator) Err() error { return p.err }
+
+// RequestID returns request id of the last request.
+func (p *Paginator) RequestID() string { return p.requestID }
+
+// Total returns the number of items returned by Count.
+func (p *Paginator) Total() int32 { return p.total }
+
+// Fetched returns the number of items fetched.
+func (p *Paginator) Fetched() int32 { return p.fetched }
+
+// ForEach calls f for each item.
+// If f returns ErrStopIteration, ForEach stops and returns nil.
+func (p *Paginator) ForEach(f func(item interface{}) error) error {
+	for p.Next() {
+		page := p.Page()
+		for i := 0; i < page.Len(); i++ {
+			if err := f(page.Index(i)); err != nil {
+				p.finish(nil)
+				if errors.Is(err, ErrStopIteration) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+	return p.Err()
+}
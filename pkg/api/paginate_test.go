@@ -0,0 +1,203 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("Paginate", func() {
+	var (
+		srv   *testSpecServer
+		c     *api.Client
+		spec  *TestSpecCountableList
+		p     *api.Paginator
+		pages [][]TestSpec
+		err   error
+	)
+	BeforeEach(func() {
+		srv = newTestSpecServer(5)
+		c = srv.NewClient()
+		spec = &TestSpecCountableList{}
+		pages = nil
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	When("fetches all pages", func() {
+		var progress [][2]int32
+		BeforeEach(func() {
+			progress = nil
+			p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2}, api.WithPageProgress(func(fetched, total int32) {
+				progress = append(progress, [2]int32{fetched, total})
+			}))
+			for p.Next() {
+				pages = append(pages, p.Page().(*TestSpecCountableList).Items)
+			}
+			err = p.Err()
+		})
+		It("returns pages", func() {
+			Expect(err).To(Succeed())
+			Expect(pages).To(HaveLen(3))
+			Expect(pages[0]).To(HaveLen(2))
+			Expect(pages[2]).To(HaveLen(1))
+			Expect(pages[2][0].ID).To(Equal("id4"))
+			Expect(p.Total()).To(Equal(int32(5)))
+			Expect(p.Fetched()).To(Equal(int32(5)))
+			Expect(p.RequestID()).To(Equal("REQ4"))
+		})
+		It("calls progress callback", func() {
+			Expect(progress).To(Equal([][2]int32{{2, 5}, {4, 5}, {5, 5}}))
+		})
+		It("does not modify spec", func() {
+			Expect(spec.Items).To(BeEmpty())
+		})
+	})
+	When("params is given", func() {
+		var params *api.CommonSearchParams
+		BeforeEach(func() {
+			params = &api.CommonSearchParams{Limit: 2}
+			p = api.Paginate(context.Background(), c, spec, params)
+			for p.Next() {
+				pages = append(pages, p.Page().(*TestSpecCountableList).Items)
+			}
+			err = p.Err()
+		})
+		It("does not modify params", func() {
+			Expect(err).To(Succeed())
+			Expect(pages).To(HaveLen(3))
+			Expect(params).To(Equal(&api.CommonSearchParams{Limit: 2}))
+		})
+	})
+	When("params is nil", func() {
+		BeforeEach(func() {
+			p = api.Paginate(context.Background(), c, spec, nil)
+			for p.Next() {
+				pages = append(pages, p.Page().(*TestSpecCountableList).Items)
+			}
+			err = p.Err()
+		})
+		It("uses max limit", func() {
+			Expect(err).To(Succeed())
+			Expect(pages).To(HaveLen(1))
+			Expect(pages[0]).To(HaveLen(5))
+		})
+	})
+	When("no items", func() {
+		BeforeEach(func() {
+			srv.SetItems(0)
+			p = api.Paginate(context.Background(), c, spec, nil)
+		})
+		It("returns no page", func() {
+			Expect(p.Next()).To(BeFalse())
+			Expect(p.Err()).To(Succeed())
+			Expect(srv.Calls()).To(Equal(1))
+		})
+	})
+	When("count request failed", func() {
+		BeforeEach(func() {
+			srv.Close()
+			p = api.Paginate(context.Background(), c, spec, nil)
+		})
+		It("returns error", func() {
+			Expect(p.Next()).To(BeFalse())
+			Expect(p.Err()).To(HaveOccurred())
+		})
+	})
+	Context("ForEach", func() {
+		var ids []string
+		BeforeEach(func() {
+			ids = nil
+		})
+		When("callback returns ErrStopIteration", func() {
+			BeforeEach(func() {
+				p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2})
+				err = p.ForEach(func(item interface{}) error {
+					ids = append(ids, item.(TestSpec).ID)
+					if len(ids) == 3 {
+						return api.ErrStopIteration
+					}
+					return nil
+				})
+			})
+			It("stops early", func() {
+				Expect(err).To(Succeed())
+				Expect(ids).To(Equal([]string{"id0", "id1", "id2"}))
+				// count + 2 pages
+				Expect(srv.Calls()).To(Equal(3))
+				Expect(p.Next()).To(BeFalse())
+			})
+		})
+		When("callback returns error", func() {
+			BeforeEach(func() {
+				p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2})
+				err = p.ForEach(func(item interface{}) error {
+					return errors.New("callback error")
+				})
+			})
+			It("returns error", func() {
+				Expect(err).To(MatchError("callback error"))
+			})
+		})
+	})
+	Context("count changes between pages", func() {
+		When("items are deleted", func() {
+			BeforeEach(func() {
+				p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2})
+				Expect(p.Next()).To(BeTrue())
+				srv.SetItems(3)
+				for p.Next() {
+					pages = append(pages, p.Page().(*TestSpecCountableList).Items)
+				}
+				err = p.Err()
+			})
+			It("stops at short page", func() {
+				Expect(err).To(Succeed())
+				Expect(pages).To(HaveLen(1))
+				Expect(p.Fetched()).To(Equal(int32(3)))
+			})
+		})
+		When("items are deleted with WithStrictCount", func() {
+			BeforeEach(func() {
+				p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2}, api.WithStrictCount())
+				Expect(p.Next()).To(BeTrue())
+				srv.SetItems(2)
+				for p.Next() {
+				}
+				err = p.Err()
+			})
+			It("returns ErrCountChanged", func() {
+				Expect(errors.Is(err, api.ErrCountChanged)).To(BeTrue())
+			})
+		})
+		When("items are added with WithStrictCount", func() {
+			BeforeEach(func() {
+				p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2}, api.WithStrictCount())
+				Expect(p.Next()).To(BeTrue())
+				srv.SetItems(8)
+				for p.Next() {
+				}
+				err = p.Err()
+			})
+			It("returns ErrCountChanged", func() {
+				Expect(errors.Is(err, api.ErrCountChanged)).To(BeTrue())
+			})
+		})
+		When("count is not changed with WithStrictCount", func() {
+			BeforeEach(func() {
+				p = api.Paginate(context.Background(), c, spec, &api.CommonSearchParams{Limit: 2}, api.WithStrictCount())
+				for p.Next() {
+				}
+				err = p.Err()
+			})
+			It("succeeds", func() {
+				Expect(err).To(Succeed())
+			})
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy is retry setting for Client.Do.
+// TooManyRequests is retried for all actions, because the server rejects the request before processing it.
+// Other server errors are retried only for IdempotentActions.
+type RetryPolicy struct {
+	// number of attempts including the first request
+	MaxAttempts int
+	// wait time before the first retry
+	InitialInterval time.Duration
+	// max wait time of backoff
+	MaxInterval time.Duration
+	// backoff multiplier
+	Multiplier float64
+	// randomization factor of backoff (0.0 - 1.0)
+	Jitter float64
+	// actions which are safe to replay on server errors
+	IdempotentActions []Action
+	// status codes which are retried for IdempotentActions
+	RetryableStatusCodes []int
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     4,
+		InitialInterval: time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2.0,
+		Jitter:          0.2,
+		IdempotentActions: []Action{
+			ActionRead,
+			ActionList,
+			ActionCount,
+			ActionUpdate,
+			ActionDelete,
+			ActionCancel,
+		},
+		RetryableStatusCodes: []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func (p *RetryPolicy) IsIdempotent(action Action) bool {
+	for _, a := range p.IdempotentActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldRetry returns true, if the request of action can be sent again.
+// attempt is number of requests already sent.
+func (p *RetryPolicy) ShouldRetry(action Action, statusCode int, attempt int) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !p.IsIdempotent(action) {
+		return false
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns wait time before next request.
+// attempt is number of requests already sent.
+// If retryAfter is longer than backoff, it returns retryAfter.
+func (p *RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := float64(p.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxInterval > 0 && wait > float64(p.MaxInterval) {
+		wait = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	d := time.Duration(wait)
+	if retryAfter > d {
+		return retryAfter
+	}
+	return d
+}
+
+// ParseRetryAfter returns duration of Retry-After header value.
+// value is delay-seconds or HTTP-date.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,200 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("retry", func() {
+	Context("RetryPolicy", func() {
+		var policy *api.RetryPolicy
+		BeforeEach(func() {
+			policy = api.DefaultRetryPolicy()
+			policy.Jitter = 0
+		})
+		Context("ShouldRetry", func() {
+			When("status code is 429", func() {
+				It("returns true for all actions", func() {
+					for _, action := range api.Actions() {
+						Expect(policy.ShouldRetry(action, http.StatusTooManyRequests, 1)).To(BeTrue(), string(action))
+					}
+				})
+			})
+			When("status code is 500", func() {
+				It("returns true for idempotent actions", func() {
+					Expect(policy.ShouldRetry(api.ActionRead, http.StatusInternalServerError, 1)).To(BeTrue())
+					Expect(policy.ShouldRetry(api.ActionDelete, http.StatusInternalServerError, 1)).To(BeTrue())
+				})
+				It("returns false for ActionCreate and ActionApply", func() {
+					Expect(policy.ShouldRetry(api.ActionCreate, http.StatusInternalServerError, 1)).To(BeFalse())
+					Expect(policy.ShouldRetry(api.ActionApply, http.StatusGatewayTimeout, 1)).To(BeFalse())
+				})
+			})
+			When("status code is 400", func() {
+				It("returns false", func() {
+					Expect(policy.ShouldRetry(api.ActionRead, http.StatusBadRequest, 1)).To(BeFalse())
+				})
+			})
+			When("attempt reaches MaxAttempts", func() {
+				It("returns false", func() {
+					Expect(policy.ShouldRetry(api.ActionRead, http.StatusTooManyRequests, policy.MaxAttempts)).To(BeFalse())
+				})
+			})
+		})
+		Context("Backoff", func() {
+			It("returns exponential backoff", func() {
+				Expect(policy.Backoff(1, 0)).To(Equal(time.Second))
+				Expect(policy.Backoff(2, 0)).To(Equal(2 * time.Second))
+				Expect(policy.Backoff(3, 0)).To(Equal(4 * time.Second))
+			})
+			It("does not exceed MaxInterval", func() {
+				Expect(policy.Backoff(10, 0)).To(Equal(policy.MaxInterval))
+			})
+			It("honors retryAfter", func() {
+				Expect(policy.Backoff(1, 5*time.Second)).To(Equal(5 * time.Second))
+			})
+			When("Jitter is set", func() {
+				BeforeEach(func() {
+					policy.Jitter = 0.5
+				})
+				It("returns randomized value", func() {
+					for i := 0; i < 10; i++ {
+						d := policy.Backoff(2, 0)
+						Expect(d).To(BeNumerically(">=", time.Second))
+						Expect(d).To(BeNumerically("<=", 3*time.Second))
+					}
+				})
+			})
+		})
+		Context("ParseRetryAfter", func() {
+			now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+			It("parses delay-seconds", func() {
+				Expect(api.ParseRetryAfter("3", now)).To(Equal(3 * time.Second))
+			})
+			It("parses HTTP-date", func() {
+				Expect(api.ParseRetryAfter("Fri, 01 Jan 2021 00:00:10 GMT", now)).To(Equal(10 * time.Second))
+			})
+			It("returns 0 for invalid value", func() {
+				Expect(api.ParseRetryAfter("", now)).To(Equal(time.Duration(0)))
+				Expect(api.ParseRetryAfter("-1", now)).To(Equal(time.Duration(0)))
+				Expect(api.ParseRetryAfter("hoge", now)).To(Equal(time.Duration(0)))
+			})
+		})
+	})
+	Context("Client with WithRetry", func() {
+		var (
+			srv      *httptest.Server
+			c        *api.Client
+			calls    int32
+			failures int32
+			status   int
+			reqId    string
+			err      error
+		)
+		BeforeEach(func() {
+			atomic.StoreInt32(&calls, 0)
+			failures = 2
+			status = http.StatusTooManyRequests
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.Header().Set("Content-Type", "application/json")
+				if n <= failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(status)
+					_, _ = w.Write([]byte(`{"request_id": "ERR", "error_type": "TooManyRequests", "error_message": "Too many requests."}`))
+					return
+				}
+				if r.Method == http.MethodPost {
+					_, _ = w.Write([]byte(`{"request_id": "CREATED", "jobs_url": "http://localhost/jobs/CREATED"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"request_id": "OK", "result": {"id": "id1", "name": "test1", "number": 1}}`))
+			}))
+			policy := api.DefaultRetryPolicy()
+			policy.InitialInterval = 10 * time.Millisecond
+			policy.MaxInterval = 50 * time.Millisecond
+			c = api.NewClient("token", srv.URL, nil, api.WithRetry(policy))
+			c.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+		})
+		AfterEach(func() {
+			srv.Close()
+		})
+		When("server returns 429 and then succeeds", func() {
+			BeforeEach(func() {
+				reqId, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+			})
+			It("retries request", func() {
+				Expect(err).To(Succeed())
+				Expect(reqId).To(Equal("OK"))
+				Expect(atomic.LoadInt32(&calls)).To(Equal(int32(3)))
+			})
+		})
+		When("server returns 429 more than MaxAttempts", func() {
+			BeforeEach(func() {
+				failures = 10
+				reqId, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+			})
+			It("returns BadResponse", func() {
+				Expect(api.IsTooManyRequests(err)).To(BeTrue())
+				Expect(atomic.LoadInt32(&calls)).To(Equal(int32(4)))
+			})
+		})
+		When("server returns 500 for Create", func() {
+			BeforeEach(func() {
+				status = http.StatusInternalServerError
+				reqId, err = c.Create(context.Background(), &TestSpec{Name: "test1"}, nil)
+			})
+			It("does not replay request", func() {
+				Expect(api.IsSystemError(err)).To(BeTrue())
+				Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+			})
+		})
+		When("server returns 429 for Create", func() {
+			BeforeEach(func() {
+				reqId, err = c.Create(context.Background(), &TestSpec{Name: "test1"}, nil)
+			})
+			It("retries request with body", func() {
+				Expect(err).To(Succeed())
+				Expect(reqId).To(Equal("CREATED"))
+				Expect(atomic.LoadInt32(&calls)).To(Equal(int32(3)))
+			})
+		})
+		When("context is canceled while waiting", func() {
+			BeforeEach(func() {
+				policy := api.DefaultRetryPolicy()
+				policy.InitialInterval = time.Minute
+				policy.MaxInterval = time.Minute
+				c = api.NewClient("token", srv.URL, nil, api.WithRetry(policy))
+				c.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+				reqId, err = c.Read(ctx, &TestSpec{ID: "id1"})
+			})
+			It("returns context error", func() {
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+				Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+			})
+		})
+		When("retry is disabled", func() {
+			BeforeEach(func() {
+				c = api.NewClient("token", srv.URL, nil)
+				c.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+				reqId, err = c.Read(context.Background(), &TestSpec{ID: "id1"})
+			})
+			It("returns BadResponse immediately", func() {
+				Expect(api.IsTooManyRequests(err)).To(BeTrue())
+				Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+			})
+		})
+	})
+})
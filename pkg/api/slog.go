@@ -0,0 +1,60 @@
+//go:build go1.21
+// +build go1.21
+
+package api
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLevelTrace is slog level of LogLevelTrace.
+const SlogLevelTrace = slog.LevelDebug - 4
+
+var (
+	_ StructuredLogger = &SlogLogger{}
+	_ LevelEnabler     = &SlogLogger{}
+)
+
+// SlogLogger makes log/slog Logger be StructuredLogger.
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+// NewSlogLogger returns SlogLogger.
+// If logger is nil, slog.Default() is used.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{Logger: logger}
+}
+
+// Enabled reports whether Logger handles records at level.
+func (s *SlogLogger) Enabled(ctx context.Context, level LogLevel) bool {
+	return s.Logger.Enabled(ctx, slogLevel(level))
+}
+
+func (s *SlogLogger) Log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		if bs, ok := field.Value.([]byte); ok {
+			attrs = append(attrs, slog.String(field.Key, string(bs)))
+			continue
+		}
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	s.Logger.LogAttrs(ctx, slogLevel(level), msg, attrs...)
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch {
+	case level <= LogLevelTrace:
+		return SlogLevelTrace
+	case level <= LogLevelDebug:
+		return slog.LevelDebug
+	case level <= LogLevelInfo:
+		return slog.LevelInfo
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+// +build go1.21
+
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+)
+
+var _ = Describe("SlogLogger", func() {
+	var (
+		buf    *bytes.Buffer
+		logger *api.SlogLogger
+	)
+	BeforeEach(func() {
+		buf = bytes.NewBuffer(nil)
+		logger = api.NewSlogLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: api.SlogLevelTrace})))
+	})
+	It("writes attributes", func() {
+		logger.Log(context.Background(), api.LogLevelDebug, "response", api.Field("status", 200), api.Field("body", []byte("{}")))
+		Expect(buf.String()).To(MatchRegexp(`level=DEBUG msg=response status=200 body={}`))
+	})
+	It("writes trace level", func() {
+		logger.Log(context.Background(), api.LogLevelTrace, "request body")
+		Expect(buf.String()).To(MatchRegexp(`level=DEBUG-4 msg="request body"`))
+	})
+	It("uses slog.Default for nil", func() {
+		Expect(api.NewSlogLogger(nil).Logger).To(Equal(slog.Default()))
+	})
+})
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LogLevel is level of StructuredLogger.
+// The values are same as StdLogger.LogLevel.
+type LogLevel int
+
+const (
+	LogLevelTrace LogLevel = 0
+	LogLevelDebug LogLevel = 1
+	LogLevelInfo  LogLevel = 2
+	LogLevelError LogLevel = 4
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelTrace:
+		return "TRACE"
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// LogField is key/value pair of structured log.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Field returns LogField.
+func Field(key string, value interface{}) LogField {
+	return LogField{Key: key, Value: value}
+}
+
+// StructuredLogger is logger interface with key/value fields.
+// Client uses the following keys.
+//
+//	method, url, status, request_id, duration, attempt, wait, body
+type StructuredLogger interface {
+	Log(ctx context.Context, level LogLevel, msg string, fields ...LogField)
+}
+
+// LevelEnabler is implemented by loggers which can report whether level is logged.
+// Client skips building fields (e.g. redacting body) of disabled levels.
+type LevelEnabler interface {
+	Enabled(ctx context.Context, level LogLevel) bool
+}
+
+var (
+	_ StructuredLogger = &LoggerAdapter{}
+	_ LevelEnabler     = &LoggerAdapter{}
+)
+
+// LoggerAdapter makes Logger be StructuredLogger.
+// The message is written as `msg key=value key=value`.
+type LoggerAdapter struct {
+	Logger Logger
+}
+
+func NewLoggerAdapter(logger Logger) *LoggerAdapter {
+	return &LoggerAdapter{Logger: logger}
+}
+
+// Enabled returns false, if Logger implements LevelEnabler and level is disabled.
+func (a *LoggerAdapter) Enabled(ctx context.Context, level LogLevel) bool {
+	if e, ok := a.Logger.(LevelEnabler); ok {
+		return e.Enabled(ctx, level)
+	}
+	return true
+}
+
+func (a *LoggerAdapter) Log(_ context.Context, level LogLevel, msg string, fields ...LogField) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if bs, ok := field.Value.([]byte); ok {
+			value = string(bs)
+		}
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = "`" + value + "`"
+		}
+		fmt.Fprintf(&b, " %s=%s", field.Key, value)
+	}
+	switch {
+	case level <= LogLevelTrace:
+		a.Logger.Tracef("%s", b.String())
+	case level <= LogLevelDebug:
+		a.Logger.Debugf("%s", b.String())
+	case level <= LogLevelInfo:
+		a.Logger.Infof("%s", b.String())
+	default:
+		a.Logger.Errorf("%s", b.String())
+	}
+}
+
+// RedactedValue is written instead of secret values, it is also used by testtool.Cassette.
+const RedactedValue = "[REDACTED]"
+
+// secretAttributes matches JSON string attribute which has secret value.
+var secretAttributes = regexp.MustCompile(`("(?i:secret|password|token|access_token|private_key)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// RedactSecrets replaces values of secret-bearing JSON attributes (e.g. secret of tsig) with RedactedValue.
+func RedactSecrets(s string) string {
+	return secretAttributes.ReplaceAllString(s, `${1}"`+RedactedValue+`"`)
+}
+
+// redactField redacts token and secret attributes in string value.
+func redactField(field LogField, token string) LogField {
+	var s string
+	switch v := field.Value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return field
+	}
+	if token != "" {
+		s = strings.ReplaceAll(s, token, RedactedValue)
+	}
+	return LogField{Key: field.Key, Value: RedactSecrets(s)}
+}
+
+// getStructuredLogger returns StructuredLogger of the client, Logger is wrapped by LoggerAdapter.
+func (c *Client) getStructuredLogger() StructuredLogger {
+	if c.structuredLogger != nil {
+		return c.structuredLogger
+	}
+	return c.loggerAdapter
+}
+
+func (c *Client) logEnabled(ctx context.Context, level LogLevel) bool {
+	if e, ok := c.getStructuredLogger().(LevelEnabler); ok {
+		return e.Enabled(ctx, level)
+	}
+	return true
+}
+
+func (c *Client) log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	if !c.logEnabled(ctx, level) {
+		return
+	}
+	for i := range fields {
+		fields[i] = redactField(fields[i], c.Token)
+	}
+	c.getStructuredLogger().Log(ctx, level, msg, fields...)
+}
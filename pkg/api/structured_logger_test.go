@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	. "github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+type logEntry struct {
+	level  api.LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (c *captureLogger) Log(_ context.Context, level api.LogLevel, msg string, fields ...api.LogField) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := logEntry{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, field := range fields {
+		e.fields[field.Key] = field.Value
+	}
+	c.entries = append(c.entries, e)
+}
+
+// levelLogger is captureLogger which implements api.LevelEnabler.
+type levelLogger struct {
+	captureLogger
+	level api.LogLevel
+}
+
+func (l *levelLogger) Enabled(_ context.Context, level api.LogLevel) bool {
+	return level >= l.level
+}
+
+func (c *captureLogger) find(msg string) *logEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.entries {
+		if c.entries[i].msg == msg {
+			return &c.entries[i]
+		}
+	}
+	return nil
+}
+
+var _ = Describe("structured logger", func() {
+	Context("LoggerAdapter", func() {
+		var (
+			buf     *bytes.Buffer
+			adapter *api.LoggerAdapter
+		)
+		BeforeEach(func() {
+			buf = bytes.NewBuffer(nil)
+			adapter = api.NewLoggerAdapter(api.NewStdLogger(buf, "test", 0, 1))
+		})
+		It("writes message with fields", func() {
+			adapter.Log(context.Background(), api.LogLevelDebug, "response", api.Field("status", 200), api.Field("url", "http://localhost/zones"), api.Field("body", []byte(`{"id": "m1"}`)))
+			Expect(buf.String()).To(Equal("testresponse status=200 url=http://localhost/zones body=`{\"id\": \"m1\"}`\n"))
+		})
+		It("filters by level of Logger", func() {
+			adapter.Log(context.Background(), api.LogLevelTrace, "trace")
+			Expect(buf.String()).To(BeEmpty())
+		})
+		It("reports level of StdLogger", func() {
+			Expect(adapter.Enabled(context.Background(), api.LogLevelTrace)).To(BeFalse())
+			Expect(adapter.Enabled(context.Background(), api.LogLevelDebug)).To(BeTrue())
+		})
+	})
+	Context("RedactSecrets", func() {
+		It("redacts secret attributes", func() {
+			Expect(api.RedactSecrets(`{"name":"tsig1","secret": "c2VjcmV0\"x","password":"p","description":"secret"}`)).To(Equal(`{"name":"tsig1","secret": "[REDACTED]","password":"[REDACTED]","description":"secret"}`))
+		})
+	})
+	Context("WithStructuredLogger", func() {
+		var (
+			srv    *testSpecServer
+			c      *api.Client
+			logger *captureLogger
+			err    error
+		)
+		BeforeEach(func() {
+			srv = newTestSpecServer(3)
+			logger = &captureLogger{}
+			c = srv.NewClient(api.WithStructuredLogger(logger))
+			c.Token = "very-secret-token"
+			_, err = c.Update(context.Background(), &TestSpec{ID: "id1", Name: "very-secret-token"}, nil)
+		})
+		AfterEach(func() {
+			srv.Close()
+		})
+		It("logs request and response with fields", func() {
+			Expect(err).To(Succeed())
+			req := logger.find("request")
+			Expect(req).NotTo(BeNil())
+			Expect(req.level).To(Equal(api.LogLevelDebug))
+			Expect(req.fields).To(HaveKeyWithValue("method", "PATCH"))
+			Expect(req.fields).To(HaveKeyWithValue("url", srv.URL+"/tests/id1"))
+			res := logger.find("response")
+			Expect(res).NotTo(BeNil())
+			Expect(res.fields).To(HaveKeyWithValue("status", 200))
+			Expect(res.fields).To(HaveKeyWithValue("request_id", "REQ1"))
+			Expect(res.fields).To(HaveKey("duration"))
+		})
+		It("redacts token", func() {
+			body := logger.find("request body")
+			Expect(body).NotTo(BeNil())
+			Expect(body.level).To(Equal(api.LogLevelTrace))
+			Expect(body.fields["body"]).To(Equal(`{"name":"[REDACTED]","number":0}`))
+		})
+	})
+	Context("LevelEnabler", func() {
+		var (
+			srv    *testSpecServer
+			logger *levelLogger
+			err    error
+		)
+		BeforeEach(func() {
+			srv = newTestSpecServer(3)
+			logger = &levelLogger{level: api.LogLevelInfo}
+			c := srv.NewClient(api.WithStructuredLogger(logger))
+			_, err = c.Update(context.Background(), &TestSpec{ID: "id1", Name: "test"}, nil)
+		})
+		AfterEach(func() {
+			srv.Close()
+		})
+		It("doesn't log disabled levels", func() {
+			Expect(err).To(Succeed())
+			Expect(logger.entries).To(BeEmpty())
+		})
+	})
+})
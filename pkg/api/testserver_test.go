@@ -0,0 +1,111 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+)
+
+// testSpecServer is stand-in server for TestSpec, TestSpecList and TestSpecCountableList.
+type testSpecServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	items      []map[string]interface{}
+	calls      int
+	failOffset int
+}
+
+func newTestSpecServer(num int) *testSpecServer {
+	s := &testSpecServer{failOffset: -1}
+	for i := 0; i < num; i++ {
+		s.items = append(s.items, map[string]interface{}{
+			"id":     fmt.Sprintf("id%d", i),
+			"name":   fmt.Sprintf("test%d", i),
+			"number": i,
+		})
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *testSpecServer) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *testSpecServer) SetItems(num int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = s.items[:0]
+	for i := 0; i < num; i++ {
+		s.items = append(s.items, map[string]interface{}{
+			"id":     fmt.Sprintf("id%d", i),
+			"name":   fmt.Sprintf("test%d", i),
+			"number": i,
+		})
+	}
+}
+
+// SetFailOffset makes list request of offset return SystemError.
+func (s *testSpecServer) SetFailOffset(offset int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failOffset = offset
+}
+
+func (s *testSpecServer) NewClient(opts ...api.ClientOption) *api.Client {
+	c := api.NewClient("token", s.URL, nil, opts...)
+	c.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+	return c
+}
+
+func (s *testSpecServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	reqID := fmt.Sprintf("REQ%d", s.calls)
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	switch {
+	case r.URL.Path == "/tests/count":
+		_ = enc.Encode(map[string]interface{}{"request_id": reqID, "result": map[string]interface{}{"count": len(s.items)}})
+	case r.URL.Path == "/tests":
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit == 0 {
+			limit = 100
+		}
+		if offset == s.failOffset {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = enc.Encode(map[string]interface{}{"request_id": reqID, "error_type": "SystemError", "error_message": "System error occurred."})
+			return
+		}
+		results := []map[string]interface{}{}
+		for i := offset; i < len(s.items) && i < offset+limit; i++ {
+			results = append(results, s.items[i])
+		}
+		_ = enc.Encode(map[string]interface{}{"request_id": reqID, "results": results})
+	case strings.HasPrefix(r.URL.Path, "/tests/"):
+		id := strings.TrimPrefix(r.URL.Path, "/tests/")
+		for _, item := range s.items {
+			if item["id"] == id {
+				_ = enc.Encode(map[string]interface{}{"request_id": reqID, "result": item})
+				return
+			}
+		}
+		fallthrough
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_ = enc.Encode(map[string]interface{}{"request_id": reqID, "error_type": "NotFound", "error_message": "Specified resource not found."})
+	}
+}
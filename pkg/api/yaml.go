@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlIndent is indent of yaml file format.
+const yamlIndent = 2
+
+func MarshalOutputYAML(spec Spec) ([]byte, error) {
+	return JSON.MarshalOutputYAML(spec)
+}
+
+func UnMarshalInputYAML(bs []byte, obj Object) error {
+	return JSON.UnMarshalInputYAML(bs, obj)
+}
+
+// MarshalOutputYAMLStream returns `---` separated yaml documents of specs.
+func MarshalOutputYAMLStream(specs ...Spec) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for i, spec := range specs {
+		bs, err := JSON.MarshalOutputYAML(spec)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(bs)
+	}
+	return buf.Bytes(), nil
+}
+
+// Marshal for yaml file format.
+// The keys are the same as MarshalOutput, and they are in the same order.
+func (j *JSONAPIAdapter) MarshalOutputYAML(spec Spec) ([]byte, error) {
+	bs, err := j.MarshalOutput(spec)
+	if err != nil {
+		return nil, err
+	}
+	return JSONToYAML(bs)
+}
+
+// UnMarshal for yaml file format.
+// json is also accepted, because json is yaml.
+func (j *JSONAPIAdapter) UnMarshalInputYAML(bs []byte, obj Object) error {
+	js, err := YAMLToJSON(bs)
+	if err != nil {
+		return err
+	}
+	return j.UnMarshalInput(js, obj)
+}
+
+// JSONToYAML converts json to block style yaml, keeping order of keys.
+func JSONToYAML(bs []byte) ([]byte, error) {
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(bs, node); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	clearYAMLStyle(node)
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(yamlIndent)
+	if err := enc.Encode(node); err != nil {
+		return nil, fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// clearYAMLStyle makes flow style (json) nodes be block style.
+// Strings which look like other types are still quoted by the encoder.
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// YAMLToJSON converts a yaml document to json.
+// It returns error if bs doesn't have just one document.
+func YAMLToJSON(bs []byte) ([]byte, error) {
+	docs, err := YAMLStreamToJSON(bs)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) != 1 {
+		return nil, fmt.Errorf("yaml must have 1 document, but it has %d documents", len(docs))
+	}
+	return docs[0], nil
+}
+
+// YAMLStreamToJSON converts `---` separated yaml documents to json.
+// Empty documents are skipped.
+func YAMLStreamToJSON(bs []byte) ([][]byte, error) {
+	var docs [][]byte
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	for {
+		node := &yaml.Node{}
+		if err := dec.Decode(node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("failed to parse yaml: %w", err)
+		}
+		if len(node.Content) == 0 || isYAMLNull(node.Content[0]) {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err := writeYAMLNodeJSON(buf, node); err != nil {
+			return nil, err
+		}
+		docs = append(docs, buf.Bytes())
+	}
+}
+
+func isYAMLNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+func writeYAMLNodeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeYAMLNodeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeYAMLNodeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind == yaml.AliasNode {
+				key = key.Alias
+			}
+			if key.Kind != yaml.ScalarNode || key.ShortTag() == "!!merge" {
+				return fmt.Errorf("line %d: key of mapping must be scalar", key.Line)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, key.Value); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeYAMLNodeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, child := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYAMLNodeJSON(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		return writeYAMLScalarJSON(buf, node)
+	default:
+		return fmt.Errorf("line %d: unknown yaml node", node.Line)
+	}
+	return nil
+}
+
+func writeYAMLScalarJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		buf.WriteString("null")
+	case "!!bool", "!!int":
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		return writeJSONValue(buf, v)
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("line %d: `%s` is not supported", node.Line, node.Value)
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	default:
+		// !!str, !!timestamp and !!binary are string of json.
+		return writeJSONValue(buf, node.Value)
+	}
+	return nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %w", err)
+	}
+	buf.Write(bs)
+	return nil
+}
@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+)
+
+var _ = Describe("yaml", func() {
+	var (
+		bs    []byte
+		err   error
+		value JsonTest
+	)
+	BeforeEach(func() {
+		value = JsonTest{Name: "hogehoge"}
+	})
+	Context("MarshalOutputYAML", func() {
+		BeforeEach(func() {
+			bs, err = api.MarshalOutputYAML(&value)
+		})
+		It("returns yaml file format", func() {
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(Equal("kind: JsonTest\napiVersion: tests\nresource:\n  name: hogehoge\n"))
+		})
+	})
+	Context("MarshalOutputYAMLStream", func() {
+		BeforeEach(func() {
+			bs, err = api.MarshalOutputYAMLStream(&value, &JsonTest{Name: "book"})
+		})
+		It("returns `---` separated documents", func() {
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(Equal("kind: JsonTest\napiVersion: tests\nresource:\n  name: hogehoge\n---\nkind: JsonTest\napiVersion: tests\nresource:\n  name: book\n"))
+		})
+	})
+	Context("UnMarshalInputYAML", func() {
+		It("can read yaml", func() {
+			err = api.UnMarshalInputYAML([]byte("kind: JsonTest\napiVersion: tests\nresource:\n  name: book\n"), &value)
+			Expect(err).To(Succeed())
+			Expect(value.Name).To(Equal("book"))
+		})
+		It("can read json", func() {
+			err = api.UnMarshalInputYAML([]byte(`{"kind": "JsonTest", "apiVersion": "tests", "resource": {"name": "book"}}`), &value)
+			Expect(err).To(Succeed())
+			Expect(value.Name).To(Equal("book"))
+		})
+		It("returns error, if yaml is invalid", func() {
+			err = api.UnMarshalInputYAML([]byte("kind: [JsonTest"), &value)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("JSONToYAML", func() {
+		It("keeps order of keys and types of values", func() {
+			js := `{"z":"123","a":"true","m":123,"n":null,"s":[1.5,"x",false],"o":{},"e":"","t":"a: b"}`
+			bs, err = api.JSONToYAML([]byte(js))
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(Equal("z: \"123\"\na: \"true\"\nm: 123\nn: null\ns:\n  - 1.5\n  - x\n  - false\no: {}\ne: \"\"\nt: 'a: b'\n"))
+			bs, err = api.YAMLToJSON(bs)
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(Equal(js))
+		})
+		It("returns error, if json is invalid", func() {
+			_, err = api.JSONToYAML([]byte(`{"a": [}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("YAMLToJSON", func() {
+		It("converts scalar by tag", func() {
+			bs, err = api.YAMLToJSON([]byte("i: 0x10\nf: 1e3\nb: true\nt: 2021-01-01T00:00:00Z\nq: '1'\nbig: 9223372036854775807\n"))
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(Equal(`{"i":16,"f":1000,"b":true,"t":"2021-01-01T00:00:00Z","q":"1","big":9223372036854775807}`))
+		})
+		It("resolves alias", func() {
+			bs, err = api.YAMLToJSON([]byte("a: &v [1, 2]\nb: *v\n"))
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(Equal(`{"a":[1,2],"b":[1,2]}`))
+		})
+		It("returns error, if yaml has some documents", func() {
+			_, err = api.YAMLToJSON([]byte("a: 1\n---\nb: 2\n"))
+			Expect(err).To(MatchError("yaml must have 1 document, but it has 2 documents"))
+		})
+		It("returns error, if value is not supported by json", func() {
+			_, err = api.YAMLToJSON([]byte("a: .inf\n"))
+			Expect(err).To(MatchError("line 1: `.inf` is not supported"))
+			_, err = api.YAMLToJSON([]byte("a: &v {b: 1}\nc:\n  <<: *v\n"))
+			Expect(err).To(MatchError("line 3: key of mapping must be scalar"))
+			_, err = api.YAMLToJSON([]byte("? [a]\n: 1\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("YAMLStreamToJSON", func() {
+		It("skips empty documents", func() {
+			docs, err := api.YAMLStreamToJSON([]byte("---\na: 1\n---\n# comment\n---\nb: 2\n"))
+			Expect(err).To(Succeed())
+			Expect(docs).To(HaveLen(2))
+			Expect(string(docs[0])).To(Equal(`{"a":1}`))
+			Expect(string(docs[1])).To(Equal(`{"b":2}`))
+		})
+		It("returns error, if yaml is invalid", func() {
+			_, err = api.YAMLStreamToJSON([]byte("a: 1\n---\nb: [\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -2,6 +2,7 @@ package apis
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/utils"
@@ -40,6 +41,35 @@ func SetPathParams(args []interface{}, ids ...interface{}) error {
 	return nil
 }
 
+// MaxPathParams is max number of SetPathParams arguments of specs.
+const MaxPathParams = 4
+
+// PathParamsCandidates returns candidates of SetPathParams arguments for values (e.g. command line arguments).
+// Path params are string or int64, so numeric values are tried as both string and int64.
+// The first candidate has only string values.
+//
+//	for _, args := range apis.PathParamsCandidates([]string{"b1", "10"}) {
+//		if err := s.SetPathParams(args...); err == nil {
+//			break
+//		}
+//	}
+func PathParamsCandidates(values []string) [][]interface{} {
+	candidates := [][]interface{}{{}}
+	for _, value := range values {
+		var next [][]interface{}
+		for _, c := range candidates {
+			next = append(next, append(append([]interface{}{}, c...), value))
+		}
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			for _, c := range candidates {
+				next = append(next, append(append([]interface{}{}, c...), id))
+			}
+		}
+		candidates = next
+	}
+	return candidates
+}
+
 type Spec interface {
 	api.Spec
 	Params
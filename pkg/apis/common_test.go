@@ -76,4 +76,17 @@ var _ = Describe("apis", func() {
 			})
 		})
 	})
+	Context("PathParamsCandidates", func() {
+		It("returns numeric values as string and int64", func() {
+			Expect(apis.PathParamsCandidates([]string{"b1", "10", "20"})).To(Equal([][]interface{}{
+				{"b1", "10", "20"},
+				{"b1", int64(10), "20"},
+				{"b1", "10", int64(20)},
+				{"b1", int64(10), int64(20)},
+			}))
+		})
+		It("returns empty args for no values", func() {
+			Expect(apis.PathParamsCandidates(nil)).To(Equal([][]interface{}{{}}))
+		})
+	})
 })
@@ -5,6 +5,7 @@ import (
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 )
 
 type CcNoticeLang string
@@ -79,4 +80,5 @@ func (c *CcNoticeAccountList) Init() {
 
 func init() {
 	register(&CcNoticeAccount{}, &CcNoticeAccountList{})
+	schema.AddEnum(CcNoticeLangJA, CcNoticeLangENUS)
 }
@@ -84,5 +84,5 @@ type CommonConfigListSearchKeywords struct {
 func (s *CommonConfigListSearchKeywords) GetValues() (url.Values, error) { return query.Values(s) }
 
 func init() {
-	register(&CommonConfigList{})
+	register(&CommonConfig{}, &CommonConfigList{})
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/jarcoal/httpmock"
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
 	"github.com/mimuret/golang-iij-dpf/pkg/types"
 )
@@ -230,6 +231,18 @@ var _ = Describe("common_configs", func() {
 				})
 			})
 		})
+		Context("file format", func() {
+			It("is registered in schema", func() {
+				bs, err := api.MarshalOutput(&s1)
+				Expect(err).To(Succeed())
+				spec, err := schema.SchemaSet.Parse(bs)
+				Expect(err).To(Succeed())
+				Expect(spec).To(Equal(&s1))
+				spec, err = schema.SchemaSet.Lookup("", "CommonConfig")
+				Expect(err).To(Succeed())
+				Expect(spec).To(Equal(&contracts.CommonConfig{}))
+			})
+		})
 		Context("api.Spec common test", func() {
 			var nilSpec *contracts.CommonConfig
 			testtool.TestDeepCopyObject(&s1, nilSpec)
@@ -6,6 +6,7 @@ import (
 	"github.com/google/go-querystring/query"
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 )
 
 var _ ChildSpec = &Tsig{}
@@ -95,4 +96,5 @@ func (s *TsigListSearchKeywords) GetValues() (url.Values, error) { return query.
 
 func init() {
 	register(&Tsig{}, &TsigList{})
+	schema.AddEnum(TsigAlgorithmHMACSHA256)
 }
@@ -74,3 +74,8 @@ func GetPathMethodForCountableListSpec(action api.Action, s ListSpec) (string, s
 	}
 	return "", ""
 }
+
+// hasJSONValue returns true if bs is not empty and not null.
+func hasJSONValue(bs []byte) bool {
+	return len(bs) > 0 && string(bs) != "null"
+}
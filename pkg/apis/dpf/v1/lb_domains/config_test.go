@@ -9,7 +9,9 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
 )
 
@@ -297,6 +299,15 @@ var _ = Describe("Config", func() {
 				Expect(cl.RequestBody["/lb_domains/b0000000000001/config"]).To(MatchJSON(configUpdateJson))
 			})
 		})
+		Context("yaml file format", func() {
+			It("can round-trip", func() {
+				bs, err := api.MarshalOutputYAML(&s1)
+				Expect(err).To(Succeed())
+				spec, err := schema.SchemaSet.ParseYAML(bs)
+				Expect(err).To(Succeed())
+				Expect(spec).To(Equal(&s1))
+			})
+		})
 		Context("SetPathParams", func() {
 			When("no arguments, nothing to do", func() {
 				BeforeEach(func() {
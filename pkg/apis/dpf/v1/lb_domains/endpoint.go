@@ -39,6 +39,22 @@ func (m *MonitoringEndpoint) UnmarshalJSON(bs []byte) error {
 	return nil
 }
 
+// UnmarshalInput parses file format.
+func (m *MonitoringEndpoint) UnmarshalInput(bs []byte) error {
+	c := struct {
+		MonitoringResourceName string
+		Enabled                bool
+		Monitoring             *Monitoring
+	}{}
+	if err := api.JSON.JSON.Unmarshal(bs, &c); err != nil {
+		return fmt.Errorf("failed to parse MonitoringEndpoint: %w", err)
+	}
+	m.MonitoringResourceName = c.MonitoringResourceName
+	m.Enabled = c.Enabled
+	m.Monitoring = c.Monitoring
+	return nil
+}
+
 type EndpointRdata struct {
 	Value string `read:"value" create:"value" update:"value" apply:"value"`
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 )
 
 var _ ChildSpec = &Monitoring{}
@@ -49,6 +50,20 @@ func (m *Monitoring) SetProps(props MonitoringPorps) {
 	m.Props = props
 }
 
+func newMonitoringPorps(mtype MonitoringMtype) (MonitoringPorps, error) {
+	switch mtype {
+	case MonitoringMtypePing:
+		return &MonitoringPorpsPING{}, nil
+	case MonitoringMtypeTCP:
+		return &MonitoringPorpsTCP{}, nil
+	case MonitoringMtypeHTTP:
+		return &MonitoringPorpsHTTP{}, nil
+	case MonitoringMtypeStatic:
+		return &MonitoringPorpsStatic{}, nil
+	}
+	return nil, fmt.Errorf("unknown mtype `%s`", mtype)
+}
+
 func (m *Monitoring) UnmarshalJSON(bs []byte) error {
 	c := struct {
 		MonitoringCommon
@@ -57,18 +72,9 @@ func (m *Monitoring) UnmarshalJSON(bs []byte) error {
 	if err := api.UnmarshalRead(bs, &c); err != nil {
 		return fmt.Errorf("failed to parse Monitoring: %w", err)
 	}
-	var props MonitoringPorps
-	switch c.MType {
-	case MonitoringMtypePing:
-		props = &MonitoringPorpsPING{}
-	case MonitoringMtypeTCP:
-		props = &MonitoringPorpsTCP{}
-	case MonitoringMtypeHTTP:
-		props = &MonitoringPorpsHTTP{}
-	case MonitoringMtypeStatic:
-		props = &MonitoringPorpsStatic{}
-	default:
-		return fmt.Errorf("unknown mtype `%s`", c.MType)
+	props, err := newMonitoringPorps(c.MType)
+	if err != nil {
+		return err
 	}
 	if err := api.UnmarshalRead(c.Props, props); err != nil {
 		return fmt.Errorf("failed to parse props: %w", err)
@@ -78,6 +84,31 @@ func (m *Monitoring) UnmarshalJSON(bs []byte) error {
 	return nil
 }
 
+// UnmarshalInput parses file format.
+func (m *Monitoring) UnmarshalInput(bs []byte) error {
+	c := struct {
+		AttributeMeta
+		MonitoringCommon
+		Props json.RawMessage
+	}{}
+	if err := api.JSON.JSON.Unmarshal(bs, &c); err != nil {
+		return fmt.Errorf("failed to parse Monitoring: %w", err)
+	}
+	props, err := newMonitoringPorps(c.MType)
+	if err != nil {
+		return err
+	}
+	if hasJSONValue(c.Props) {
+		if err := api.JSON.JSON.Unmarshal(c.Props, props); err != nil {
+			return fmt.Errorf("failed to parse props: %w", err)
+		}
+	}
+	m.AttributeMeta = c.AttributeMeta
+	m.MonitoringCommon = c.MonitoringCommon
+	m.SetProps(props)
+	return nil
+}
+
 func (c *Monitoring) GetName() string                     { return "monitorings" }
 func (c *Monitoring) GetResourceName() string             { return c.ResourceName }
 func (c *Monitoring) SetResourceName(resourceName string) { c.ResourceName = resourceName }
@@ -118,4 +149,8 @@ func (c *MonitoringList) SetPathParams(args ...interface{}) error {
 
 func init() {
 	register(&Monitoring{}, &MonitoringList{})
+	schema.AddEnum(MonitoringMtypePing, MonitoringMtypeTCP, MonitoringMtypeHTTP, MonitoringMtypeStatic)
+	schema.AddEnum(MonitoringPropsLocationAll, MonitoringPropsLocationJP, MonitoringPropsLocationUS)
+	schema.AddEnum(MonitoringPorpsStaticStatusUp, MonitoringPorpsStaticStatusDown, MonitoringPorpsStaticStatusUnkown)
+	schema.AddImplementations((*MonitoringPorps)(nil), &MonitoringPorpsPING{}, &MonitoringPorpsTCP{}, &MonitoringPorpsHTTP{}, &MonitoringPorpsStatic{})
 }
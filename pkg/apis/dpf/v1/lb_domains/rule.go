@@ -3,6 +3,7 @@ package lb_domains
 import (
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 )
 
 type RuleRRType string
@@ -82,4 +83,5 @@ func (c *RuleList) SetPathParams(args ...interface{}) error {
 
 func init() {
 	register(&Rule{}, &RuleList{})
+	schema.AddEnum(RuleRRTypeA, RuleRRTypeAAAA, RuleRRTypeCNAME)
 }
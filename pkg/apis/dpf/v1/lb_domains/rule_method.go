@@ -7,6 +7,7 @@ import (
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 )
 
 var _ Spec = &RuleMethod{}
@@ -37,6 +38,24 @@ func (c *RuleMethod) Fix() {
 	}
 }
 
+func newRuleMethodProps(mtype RuleMethodMType) (RuleMethodProps, error) {
+	switch mtype {
+	case "entry_a":
+		return &RuleMethodEntryA{}, nil
+	case "entry_aaaa":
+		return &RuleMethodEntryAAAA{}, nil
+	case "entry_cname":
+		return &RuleMethodEntryCNAME{}, nil
+	case "exit_site":
+		return &RuleMethodExitSite{}, nil
+	case "exit_sorry":
+		return &RuleMethodExitSorry{}, nil
+	case "failover":
+		return &RuleMethodFailover{}, nil
+	}
+	return nil, fmt.Errorf("unknown mtype `%s`", mtype)
+}
+
 func (c *RuleMethod) UnmarshalJSON(bs []byte) error {
 	r := struct {
 		Priority *uint           `read:"priority"`
@@ -49,23 +68,9 @@ func (c *RuleMethod) UnmarshalJSON(bs []byte) error {
 	if err := api.UnmarshalRead(r.Method, propsCommon); err != nil {
 		return fmt.Errorf("failed to parse Method: %w", err)
 	}
-
-	var props RuleMethodProps
-	switch propsCommon.MType {
-	case "entry_a":
-		props = &RuleMethodEntryA{}
-	case "entry_aaaa":
-		props = &RuleMethodEntryAAAA{}
-	case "entry_cname":
-		props = &RuleMethodEntryCNAME{}
-	case "exit_site":
-		props = &RuleMethodExitSite{}
-	case "exit_sorry":
-		props = &RuleMethodExitSorry{}
-	case "failover":
-		props = &RuleMethodFailover{}
-	default:
-		return fmt.Errorf("unknown mtype `%s`", propsCommon.MType)
+	props, err := newRuleMethodProps(propsCommon.MType)
+	if err != nil {
+		return err
 	}
 	if err := api.UnmarshalRead(r.Method, props); err != nil {
 		return fmt.Errorf("failed to parse props: %w", err)
@@ -75,9 +80,51 @@ func (c *RuleMethod) UnmarshalJSON(bs []byte) error {
 	return nil
 }
 
-func (c *RuleMethod) GetName() string               { return "rule_methods" }
-func (c *RuleMethod) GetMethodResourceName() string { return c.Method.GetMethodResourceName() }
+// UnmarshalInput parses file format.
+func (c *RuleMethod) UnmarshalInput(bs []byte) error {
+	r := struct {
+		RuleAttributeMeta
+		Priority *uint
+		Method   json.RawMessage
+	}{}
+	if err := api.JSON.JSON.Unmarshal(bs, &r); err != nil {
+		return fmt.Errorf("failed to parse RuleMethod: %w", err)
+	}
+	c.RuleAttributeMeta = r.RuleAttributeMeta
+	c.Priority = r.Priority
+	c.Method = nil
+	if !hasJSONValue(r.Method) {
+		return nil
+	}
+	propsCommon := &RuleMethodPropsCommon{}
+	if err := api.JSON.JSON.Unmarshal(r.Method, propsCommon); err != nil {
+		return fmt.Errorf("failed to parse Method: %w", err)
+	}
+	props, err := newRuleMethodProps(propsCommon.MType)
+	if err != nil {
+		return err
+	}
+	if err := api.JSON.JSON.Unmarshal(r.Method, props); err != nil {
+		return fmt.Errorf("failed to parse props: %w", err)
+	}
+	c.Method = props
+	return nil
+}
+
+func (c *RuleMethod) GetName() string { return "rule_methods" }
+func (c *RuleMethod) GetMethodResourceName() string {
+	if c.Method == nil {
+		return ""
+	}
+	return c.Method.GetMethodResourceName()
+}
+
+// SetMethodResourceName sets resource name of Method.
+// If Method is nil, RuleMethodPropsCommon is set to keep the resource name (e.g. for Read and Delete).
 func (c *RuleMethod) SetMethodResourceName(resourceName string) {
+	if c.Method == nil {
+		c.Method = &RuleMethodPropsCommon{}
+	}
 	c.Method.SetMethodResourceName(resourceName)
 }
 
@@ -86,7 +133,7 @@ func (c *RuleMethod) GetPathMethod(action api.Action) (string, string) {
 	case api.ActionCreate:
 		return action.ToMethod(), fmt.Sprintf("/lb_domains/%s/rules/%s/rule_methods", c.GetLBDoaminID(), c.RuleResourceName)
 	case api.ActionRead, api.ActionUpdate, api.ActionDelete:
-		return action.ToMethod(), fmt.Sprintf("/lb_domains/%s/rules/%s/rule_methods/%s", c.GetLBDoaminID(), c.RuleResourceName, c.Method.GetMethodResourceName())
+		return action.ToMethod(), fmt.Sprintf("/lb_domains/%s/rules/%s/rule_methods/%s", c.GetLBDoaminID(), c.RuleResourceName, c.GetMethodResourceName())
 	}
 	return "", ""
 }
@@ -132,4 +179,6 @@ func (c *RuleMethodList) SetPathParams(args ...interface{}) error {
 
 func init() {
 	register(&RuleMethod{}, &RuleMethodList{})
+	schema.AddEnum(RuleMethodMTypeEntryA, RuleMethodMTypeEntryAAAA, RuleMethodMTypeEntryCNAME, RuleMethodMTypeExitSite, RuleMethodMTypeExitSorry, RuleMethodMTypeFailover)
+	schema.AddImplementations((*RuleMethodProps)(nil), &RuleMethodEntryA{}, &RuleMethodEntryAAAA{}, &RuleMethodEntryCNAME{}, &RuleMethodExitSite{}, &RuleMethodExitSorry{}, &RuleMethodFailover{})
 }
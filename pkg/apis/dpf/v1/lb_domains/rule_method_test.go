@@ -214,6 +214,19 @@ var _ = Describe("RuleMethod", func() {
 					Expect(s1.GetMethodResourceName()).To(Equal("method-10"))
 				})
 			})
+			When("method is nil", func() {
+				var empty lb_domains.RuleMethod
+				BeforeEach(func() {
+					empty = lb_domains.RuleMethod{}
+					err = empty.SetPathParams("b0000000000010", "rule-10", "method-10")
+				})
+				It("keeps method resource name", func() {
+					Expect(err).To(Succeed())
+					Expect(empty.GetMethodResourceName()).To(Equal("method-10"))
+					_, path := empty.GetPathMethod(api.ActionRead)
+					Expect(path).To(Equal("/lb_domains/b0000000000010/rules/rule-10/rule_methods/method-10"))
+				})
+			})
 			When("arguments has extra value", func() {
 				BeforeEach(func() {
 					err = s1.SetPathParams("b0000000000010", "rule-10", "method-10", 1)
@@ -231,6 +244,31 @@ var _ = Describe("RuleMethod", func() {
 				})
 			})
 		})
+		Context("file format", func() {
+			var in lb_domains.RuleMethod
+			BeforeEach(func() {
+				in = lb_domains.RuleMethod{}
+			})
+			It("can round-trip", func() {
+				bs, err := api.MarshalOutput(&s1)
+				Expect(err).To(Succeed())
+				err = api.UnMarshalInput(bs, &in)
+				Expect(err).To(Succeed())
+				Expect(in).To(Equal(s1))
+			})
+			When("method is omitted", func() {
+				BeforeEach(func() {
+					err = api.UnMarshalInput([]byte(`{"kind":"RuleMethod","apiVersion":"lb-domains.api.dns-platform.jp/v1","resource":{"LBDomainID":"b0000000000001","RuleResourceName":"rule-1","Priority":10}}`), &in)
+				})
+				It("parses as file format", func() {
+					Expect(err).To(Succeed())
+					Expect(in.LBDomainID).To(Equal("b0000000000001"))
+					Expect(in.RuleResourceName).To(Equal("rule-1"))
+					Expect(in.Priority).To(Equal(&priorityS1))
+					Expect(in.Method).To(BeNil())
+				})
+			})
+		})
 		Context("api.Spec common test", func() {
 			var nilSpec *lb_domains.RuleMethod
 			testtool.TestDeepCopyObject(&s1, nilSpec)
@@ -344,6 +382,31 @@ var _ = Describe("RuleMethod", func() {
 				})
 			})
 		})
+		Context("file format", func() {
+			var in lb_domains.RuleMethod
+			BeforeEach(func() {
+				in = lb_domains.RuleMethod{}
+			})
+			It("can round-trip", func() {
+				bs, err := api.MarshalOutput(&s1)
+				Expect(err).To(Succeed())
+				err = api.UnMarshalInput(bs, &in)
+				Expect(err).To(Succeed())
+				Expect(in).To(Equal(s1))
+			})
+			When("method is omitted", func() {
+				BeforeEach(func() {
+					err = api.UnMarshalInput([]byte(`{"kind":"RuleMethod","apiVersion":"lb-domains.api.dns-platform.jp/v1","resource":{"LBDomainID":"b0000000000001","RuleResourceName":"rule-1","Priority":10}}`), &in)
+				})
+				It("parses as file format", func() {
+					Expect(err).To(Succeed())
+					Expect(in.LBDomainID).To(Equal("b0000000000001"))
+					Expect(in.RuleResourceName).To(Equal("rule-1"))
+					Expect(in.Priority).To(Equal(&priorityS1))
+					Expect(in.Method).To(BeNil())
+				})
+			})
+		})
 		Context("api.Spec common test", func() {
 			var nilSpec *lb_domains.RuleMethodList
 			testtool.TestDeepCopyObject(&slist, nilSpec)
@@ -3,6 +3,7 @@ package lb_domains
 import (
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 )
 
 type SiteRRType string
@@ -76,4 +77,5 @@ func (c *SiteList) SetPathParams(args ...interface{}) error {
 
 func init() {
 	register(&Site{}, &SiteList{})
+	schema.AddEnum(SiteRRTypeA, SiteRRTypeAAAA, SiteRRTypeCNAME)
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/miekg/dns"
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 	"github.com/mimuret/golang-iij-dpf/pkg/types"
 )
 
@@ -55,6 +56,9 @@ const (
 	TypeANAME Type = "ANAME"
 )
 
+// Types are rrtypes which are supported by DPF.
+var Types = []Type{TypeSOA, TypeA, TypeAAAA, TypeCAA, TypeCNAME, TypeDS, TypeNS, TypeMX, TypeNAPTR, TypeSRV, TypeTXT, TypeTLSA, TypePTR, TypeSVCB, TypeHTTPS, TypeANAME}
+
 func (c Type) String() string {
 	return string(c)
 }
@@ -207,4 +211,9 @@ func (c *CurrentRecordList) SetPathParams(args ...interface{}) error {
 func init() {
 	register(&Record{}, &RecordList{})
 	register(&CurrentRecordList{})
+	values := make([]interface{}, 0, len(Types))
+	for _, t := range Types {
+		values = append(values, t)
+	}
+	schema.AddEnum(values...)
 }
@@ -0,0 +1,150 @@
+package zones
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/miekg/dns"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+// ToRRs returns RRset of the record.
+// Null TTL is converted to 0, it can be distinguished from real TTL
+// because DPF does not accept TTL less than RecordMinTTL.
+// miekg/dns does not have ANAME type, so ANAME is returned as *dns.RFC3597 of TypeANAMECode.
+func (c *Record) ToRRs() ([]dns.RR, error) {
+	if !dns.IsFqdn(c.Name) {
+		return nil, fmt.Errorf("name `%s` is not fully qualified domain name", c.Name)
+	}
+	if _, ok := dns.IsDomainName(c.Name); !ok {
+		return nil, fmt.Errorf("name `%s` is invalid domain name", c.Name)
+	}
+	if !recordValidTypes[c.RRType] {
+		return nil, fmt.Errorf("rrtype `%s` is not supported", c.RRType)
+	}
+	rrs := make([]dns.RR, 0, len(c.RData))
+	for i, rdata := range c.RData {
+		rr, err := parseRDATA(c.RRType, rdata.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert rdata[%d]: %w", i, err)
+		}
+		if c.RRType == TypeANAME {
+			if rr, err = cnameToANAME(rr.(*dns.CNAME)); err != nil {
+				return nil, fmt.Errorf("failed to convert rdata[%d]: %w", i, err)
+			}
+		}
+		rr.Header().Name = c.Name
+		rr.Header().Ttl = uint32(c.TTL)
+		rrs = append(rrs, rr)
+	}
+	return rrs, nil
+}
+
+// RecordFromRRs groups rrs by owner name and type, and returns a Record for each RRset.
+// Records are returned in the order of first appearance.
+// TTL 0 is converted to null TTL, see Record.ToRRs.
+// *dns.RFC3597 of TypeANAMECode is converted to ANAME record.
+// It returns error, if class is not IN or TTLs in a RRset are different.
+func RecordFromRRs(rrs []dns.RR) ([]Record, error) {
+	type key struct {
+		name   string
+		rrtype uint16
+	}
+	var records []Record
+	rrsets := map[key]int{}
+	for i, rr := range rrs {
+		h := rr.Header()
+		if h.Class != dns.ClassINET {
+			return nil, fmt.Errorf("rrs[%d]: class `%s` is not supported", i, dns.Class(h.Class))
+		}
+		if h.Ttl > math.MaxInt32 {
+			return nil, fmt.Errorf("rrs[%d]: ttl %d is out of range", i, h.Ttl)
+		}
+		rrtype, value, err := rrToRDATA(rr)
+		if err != nil {
+			return nil, fmt.Errorf("rrs[%d]: %w", i, err)
+		}
+		ttl := types.NullablePositiveInt32(h.Ttl)
+		k := key{dns.CanonicalName(h.Name), h.Rrtype}
+		j, ok := rrsets[k]
+		if !ok {
+			rrsets[k] = len(records)
+			records = append(records, Record{
+				Name:   h.Name,
+				TTL:    ttl,
+				RRType: rrtype,
+				RData:  RecordRDATASlice{{Value: value}},
+			})
+			continue
+		}
+		if records[j].TTL != ttl {
+			return nil, fmt.Errorf("rrs[%d]: ttl %d is different from %d of %s %s RRset", i, h.Ttl, records[j].TTL, records[j].Name, rrtype)
+		}
+		if !records[j].RData.contains(value) {
+			records[j].RData = append(records[j].RData, RecordRDATA{Value: value})
+		}
+	}
+	return records, nil
+}
+
+func (c RecordRDATASlice) contains(value string) bool {
+	for _, rdata := range c {
+		if rdata.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func rrToRDATA(rr dns.RR) (Type, string, error) {
+	h := rr.Header()
+	if generic, ok := rr.(*dns.RFC3597); ok {
+		if h.Rrtype != TypeANAMECode {
+			return "", "", fmt.Errorf("unknown rdata format of type %s is not supported", dns.Type(h.Rrtype))
+		}
+		target, err := anameTarget(generic)
+		if err != nil {
+			return "", "", err
+		}
+		return TypeANAME, target, nil
+	}
+	rrtype := Uint16ToType(h.Rrtype)
+	if rrtype == TypeANAME || !recordValidTypes[rrtype] {
+		return "", "", fmt.Errorf("rrtype `%s` is not supported", dns.Type(h.Rrtype))
+	}
+	return rrtype, rdataString(rr), nil
+}
+
+func cnameToANAME(cname *dns.CNAME) (dns.RR, error) {
+	buf := make([]byte, 255)
+	off, err := dns.PackDomainName(cname.Target, buf, 0, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack ANAME target: %w", err)
+	}
+	return &dns.RFC3597{
+		Hdr: dns.RR_Header{
+			Name:     cname.Hdr.Name,
+			Rrtype:   TypeANAMECode,
+			Class:    dns.ClassINET,
+			Ttl:      cname.Hdr.Ttl,
+			Rdlength: uint16(off),
+		},
+		Rdata: hex.EncodeToString(buf[:off]),
+	}, nil
+}
+
+func anameTarget(rr *dns.RFC3597) (string, error) {
+	buf, err := hex.DecodeString(rr.Rdata)
+	if err != nil {
+		return "", fmt.Errorf("invalid ANAME rdata: %w", err)
+	}
+	target, off, err := dns.UnpackDomainName(buf, 0)
+	if err != nil {
+		return "", fmt.Errorf("invalid ANAME rdata: %w", err)
+	}
+	if off != len(buf) {
+		return "", fmt.Errorf("invalid ANAME rdata: trailing data")
+	}
+	return target, nil
+}
@@ -0,0 +1,195 @@
+package zones_test
+
+import (
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+)
+
+func mustRR(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	ExpectWithOffset(1, err).To(Succeed())
+	return rr
+}
+
+var _ = Describe("records conversion", func() {
+	var (
+		r       zones.Record
+		rrs     []dns.RR
+		records []zones.Record
+		err     error
+	)
+	Context("Record.ToRRs", func() {
+		BeforeEach(func() {
+			r = zones.Record{
+				Name:   "www.example.jp.",
+				TTL:    300,
+				RRType: zones.TypeA,
+				RData:  zones.RecordRDATASlice{{Value: "192.168.0.1"}, {Value: "192.168.0.2"}},
+			}
+		})
+		When("valid record", func() {
+			BeforeEach(func() {
+				rrs, err = r.ToRRs()
+			})
+			It("returns RRset", func() {
+				Expect(err).To(Succeed())
+				Expect(rrs).To(Equal([]dns.RR{
+					mustRR("www.example.jp. 300 IN A 192.168.0.1"),
+					mustRR("www.example.jp. 300 IN A 192.168.0.2"),
+				}))
+			})
+		})
+		When("TTL is null", func() {
+			BeforeEach(func() {
+				r.TTL = 0
+				rrs, err = r.ToRRs()
+			})
+			It("returns RR with TTL 0", func() {
+				Expect(err).To(Succeed())
+				Expect(rrs[0].Header().Ttl).To(BeZero())
+			})
+		})
+		When("ANAME", func() {
+			BeforeEach(func() {
+				r.Name = "example.jp."
+				r.RRType = zones.TypeANAME
+				r.RData = zones.RecordRDATASlice{{Value: "www.example.net."}}
+				rrs, err = r.ToRRs()
+			})
+			It("returns RFC3597 RR", func() {
+				Expect(err).To(Succeed())
+				Expect(rrs).To(HaveLen(1))
+				Expect(rrs[0]).To(BeAssignableToTypeOf(&dns.RFC3597{}))
+				Expect(rrs[0].Header().Rrtype).To(Equal(zones.TypeANAMECode))
+				Expect(rrs[0].String()).To(Equal(mustRR(`example.jp. 300 IN TYPE65280 \# 17 03777777076578616d706c65036e657400`).String()))
+			})
+		})
+		When("TXT has semicolon", func() {
+			BeforeEach(func() {
+				r.RRType = zones.TypeTXT
+				r.RData = zones.RecordRDATASlice{
+					{Value: `"v=DMARC1; p=none"`},
+					{Value: `"v=DKIM1; k=rsa; " "p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC"`},
+				}
+				rrs, err = r.ToRRs()
+			})
+			It("returns RRset", func() {
+				Expect(err).To(Succeed())
+				Expect(rrs).To(Equal([]dns.RR{
+					mustRR(`www.example.jp. 300 IN TXT "v=DMARC1; p=none"`),
+					mustRR(`www.example.jp. 300 IN TXT "v=DKIM1; k=rsa; " "p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC"`),
+				}))
+			})
+			It("can be converted back", func() {
+				records, err = zones.RecordFromRRs(rrs)
+				Expect(err).To(Succeed())
+				Expect(records).To(Equal([]zones.Record{r}))
+			})
+		})
+		When("TXT is not quoted", func() {
+			BeforeEach(func() {
+				r.RRType = zones.TypeTXT
+				r.RData = zones.RecordRDATASlice{{Value: "v=spf1 include:example.net -all"}}
+				rrs, err = r.ToRRs()
+			})
+			It("returns one character-string", func() {
+				Expect(err).To(Succeed())
+				Expect(rrs).To(Equal([]dns.RR{
+					mustRR(`www.example.jp. 300 IN TXT "v=spf1 include:example.net -all"`),
+				}))
+			})
+			It("is converted back to quoted value", func() {
+				records, err = zones.RecordFromRRs(rrs)
+				Expect(err).To(Succeed())
+				Expect(records).To(HaveLen(1))
+				Expect(records[0].RData).To(Equal(zones.RecordRDATASlice{{Value: `"v=spf1 include:example.net -all"`}}))
+				value, err := zones.FormatRDATA(zones.TypeTXT, r.RData[0].Value)
+				Expect(err).To(Succeed())
+				Expect(records[0].RData[0].Value).To(Equal(value))
+			})
+		})
+		When("name is not fqdn", func() {
+			BeforeEach(func() {
+				r.Name = "www"
+				rrs, err = r.ToRRs()
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		When("rdata is invalid", func() {
+			BeforeEach(func() {
+				r.RData[1].Value = "hoge"
+				rrs, err = r.ToRRs()
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("failed to convert rdata[1]: invalid A rdata"))
+			})
+		})
+	})
+	Context("RecordFromRRs", func() {
+		BeforeEach(func() {
+			rrs = []dns.RR{
+				mustRR("www.example.jp. 300 IN A 192.168.0.1"),
+				mustRR("www.example.jp. 0 IN AAAA 2001:db8::1"),
+				mustRR("WWW.example.jp. 300 IN A 192.168.0.2"),
+				mustRR("www.example.jp. 300 IN A 192.168.0.1"),
+				mustRR(`example.jp. 300 IN TYPE65280 \# 17 03777777076578616d706c65036e657400`),
+				mustRR(`example.jp. 300 IN TXT "hoge fuga"`),
+			}
+		})
+		When("valid RRs", func() {
+			BeforeEach(func() {
+				records, err = zones.RecordFromRRs(rrs)
+			})
+			It("returns records grouped by RRset", func() {
+				Expect(err).To(Succeed())
+				Expect(records).To(Equal([]zones.Record{
+					{Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}, {Value: "192.168.0.2"}}},
+					{Name: "www.example.jp.", TTL: 0, RRType: zones.TypeAAAA, RData: zones.RecordRDATASlice{{Value: "2001:db8::1"}}},
+					{Name: "example.jp.", TTL: 300, RRType: zones.TypeANAME, RData: zones.RecordRDATASlice{{Value: "www.example.net."}}},
+					{Name: "example.jp.", TTL: 300, RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `"hoge fuga"`}}},
+				}))
+			})
+			It("can be converted back", func() {
+				for _, record := range records {
+					res, err := record.ToRRs()
+					Expect(err).To(Succeed())
+					again, err := zones.RecordFromRRs(res)
+					Expect(err).To(Succeed())
+					Expect(again).To(Equal([]zones.Record{record}))
+				}
+			})
+		})
+		When("TTLs are different in RRset", func() {
+			BeforeEach(func() {
+				rrs = append(rrs, mustRR("www.example.jp. 600 IN A 192.168.0.3"))
+				records, err = zones.RecordFromRRs(rrs)
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("rrs[6]: ttl 600"))
+			})
+		})
+		When("class is not IN", func() {
+			BeforeEach(func() {
+				records, err = zones.RecordFromRRs([]dns.RR{mustRR("www.example.jp. 300 CH A 192.168.0.1")})
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		When("type is not supported", func() {
+			BeforeEach(func() {
+				records, err = zones.RecordFromRRs([]dns.RR{mustRR("www.example.jp. 300 IN HINFO foo bar")})
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"reflect"
 
 	"github.com/miekg/dns"
 	. "github.com/onsi/ginkgo"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/jarcoal/httpmock"
 	api "github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
 )
 
@@ -309,6 +312,21 @@ var _ = Describe("records", func() {
 				})
 			})
 		})
+		Context("yaml file format", func() {
+			It("can round-trip", func() {
+				bs, err := api.MarshalOutputYAML(&s1)
+				Expect(err).To(Succeed())
+				Expect(string(bs)).To(HavePrefix("kind: Record\napiVersion: zones.api.dns-platform.jp/v1\nresource:\n  ZoneID: m1\n"))
+				c = zones.Record{}
+				Expect(api.UnMarshalInputYAML(bs, &c)).To(Succeed())
+				Expect(c).To(Equal(s1))
+				bs, err = api.MarshalOutputYAMLStream(&s1, &s2)
+				Expect(err).To(Succeed())
+				specs, err := schema.SchemaSet.ParseYAMLStream(bs)
+				Expect(err).To(Succeed())
+				Expect(specs).To(Equal([]apis.Spec{&s1, &s2}))
+			})
+		})
 		Context("SetPathParams", func() {
 			When("no arguments, nothing to do", func() {
 				BeforeEach(func() {
@@ -763,6 +781,21 @@ var _ = Describe("records", func() {
 				}
 			})
 		})
+		Context("Types", func() {
+			It("has all types", func() {
+				Expect(zones.Types).To(HaveLen(len(testcase)))
+				for i, tc := range testcase {
+					Expect(zones.Types[i]).To(Equal(tc.Type))
+				}
+			})
+			It("is enum of Type", func() {
+				values := []interface{}{}
+				for _, t := range zones.Types {
+					values = append(values, t)
+				}
+				Expect(schema.EnumValues(reflect.TypeOf(zones.TypeA))).To(Equal(values))
+			})
+		})
 	})
 	Context("RecordStateToString", func() {
 		Context("String", func() {
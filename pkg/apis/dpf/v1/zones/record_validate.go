@@ -0,0 +1,252 @@
+package zones
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	RecordMinTTL int32 = 30
+	RecordMaxTTL int32 = 2147483647
+)
+
+// FieldError is validation error of a field.
+type FieldError struct {
+	// field path, e.g. "rdata[0].value", "items[1].name"
+	Field   string
+	Value   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %q: %s", e.Field, e.Value, e.Message)
+}
+
+// FieldErrors is returned by Record.Validate and RecordList.Validate.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	res := []string{}
+	for _, fe := range e {
+		res = append(res, fe.Error())
+	}
+	return strings.Join(res, ", ")
+}
+
+func (e FieldErrors) prefix(p string) FieldErrors {
+	for _, fe := range e {
+		fe.Field = p + "." + fe.Field
+	}
+	return e
+}
+
+var recordValidTypes = func() map[Type]bool {
+	res := map[Type]bool{}
+	for _, t := range Types {
+		res[t] = true
+	}
+	return res
+}()
+
+// Validate checks Name, TTL, RRType and RData of the record without API request.
+// RData values are parsed by miekg/dns according to RRType.
+// It returns FieldErrors, if the record is invalid.
+func (c *Record) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *Record) validate() FieldErrors {
+	var errs FieldErrors
+	add := func(field, value, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Field: field, Value: value, Message: fmt.Sprintf(format, args...)})
+	}
+	switch {
+	case c.Name == "":
+		add("name", c.Name, "is required")
+	case !dns.IsFqdn(c.Name):
+		add("name", c.Name, "must be fully qualified domain name")
+	default:
+		if _, ok := dns.IsDomainName(c.Name); !ok {
+			add("name", c.Name, "is invalid domain name")
+		}
+	}
+	if c.TTL != 0 && (int32(c.TTL) < RecordMinTTL || int32(c.TTL) > RecordMaxTTL) {
+		add("ttl", fmt.Sprint(int32(c.TTL)), "must be between %d and %d", RecordMinTTL, RecordMaxTTL)
+	}
+	if !recordValidTypes[c.RRType] {
+		add("rrtype", string(c.RRType), "is unsupported type")
+		return errs
+	}
+	if len(c.RData) == 0 {
+		add("rdata", "", "is required")
+		return errs
+	}
+	if len(c.RData) > 1 && (c.RRType == TypeSOA || c.RRType == TypeCNAME || c.RRType == TypeANAME) {
+		add("rdata", c.RData.String(), "%s record must have only one value", c.RRType)
+	}
+	seen := map[string]int{}
+	for i, rdata := range c.RData {
+		field := fmt.Sprintf("rdata[%d].value", i)
+		rr, err := parseRDATA(c.RRType, rdata.Value)
+		if err != nil {
+			add(field, rdata.Value, "%s", err)
+			continue
+		}
+		normalized := rdataString(rr)
+		if j, ok := seen[normalized]; ok {
+			add(field, rdata.Value, "is duplicated with rdata[%d]", j)
+			continue
+		}
+		seen[normalized] = i
+	}
+	return errs
+}
+
+// FormatRDATA returns presentation format of rdata.
+// The value is parsed by same rule as Record.Validate and Record.ToRRs,
+// so TXT value which is not quoted is one character-string, see quoteTXT.
+func FormatRDATA(rrtype Type, value string) (string, error) {
+	rr, err := parseRDATA(rrtype, value)
+	if err != nil {
+		return "", err
+	}
+	return rdataString(rr), nil
+}
+
+// parseRDATA returns dns.RR of rdata with root owner name.
+// ANAME rdata is returned as CNAME.
+func parseRDATA(rrtype Type, value string) (dns.RR, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("is empty")
+	}
+	if rrtype == TypeTXT {
+		value = quoteTXT(value)
+	}
+	if strings.ContainsAny(value, "\n\r") || hasComment(value) {
+		return nil, fmt.Errorf("must not contain newline or comment")
+	}
+	parseType := rrtype
+	if rrtype == TypeANAME {
+		// ANAME rdata has same format as CNAME
+		parseType = TypeCNAME
+	}
+	rr, err := dns.NewRR(fmt.Sprintf(". 300 IN %s %s", parseType, value))
+	if err != nil {
+		msg := err.Error()
+		// position in the dummy line is meaningless
+		if i := strings.Index(msg, " at line: "); i >= 0 {
+			msg = msg[:i]
+		}
+		return nil, fmt.Errorf("invalid %s rdata: %s", rrtype, msg)
+	}
+	if rr == nil || rr.Header().Rrtype != parseType.Uint16() {
+		return nil, fmt.Errorf("invalid %s rdata", rrtype)
+	}
+	// miekg/dns does not check hex string on parsing
+	switch v := rr.(type) {
+	case *dns.TLSA:
+		if _, err := hex.DecodeString(v.Certificate); err != nil {
+			return nil, fmt.Errorf("invalid %s rdata: bad certificate association data", rrtype)
+		}
+	case *dns.DS:
+		if _, err := hex.DecodeString(v.Digest); err != nil {
+			return nil, fmt.Errorf("invalid %s rdata: bad digest", rrtype)
+		}
+	}
+	return rr, nil
+}
+
+// quoteTXT returns TXT rdata of value.
+// Value which is not quoted is one character-string,
+// e.g. `v=spf1 include:example.jp -all` is `"v=spf1 include:example.jp -all"`.
+func quoteTXT(value string) string {
+	if strings.HasPrefix(strings.TrimSpace(value), `"`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// hasComment returns true, if value has `;` outside of quoted strings.
+// `;` in quoted strings (e.g. TXT "v=DMARC1; p=none") is not a comment.
+func hasComment(value string) bool {
+	quoted := false
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rdataString returns presentation format of rdata.
+func rdataString(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// Validate calls Record.Validate for each item, and checks rules between records.
+//   - Name and RRType pair must be unique.
+//   - CNAME can not be coexisted with other types at same name.
+//
+// Records of ToBeDeleted or BeforeUpdate state are ignored in the rules between records.
+// It returns FieldErrors, if the list is invalid.
+func (c *RecordList) Validate() error {
+	var errs FieldErrors
+	type key struct {
+		name   string
+		rrtype Type
+	}
+	rrsets := map[key]int{}
+	names := map[string][]int{}
+	for i := range c.Items {
+		item := &c.Items[i]
+		field := fmt.Sprintf("items[%d]", i)
+		errs = append(errs, item.validate().prefix(field)...)
+		if item.State == RecordStateToBeDeleted || item.State == RecordStateBeforeUpdate || item.Name == "" {
+			continue
+		}
+		name := dns.CanonicalName(item.Name)
+		k := key{name, item.RRType}
+		if j, ok := rrsets[k]; ok {
+			errs = append(errs, &FieldError{Field: field + ".rrtype", Value: string(item.RRType), Message: fmt.Sprintf("is duplicated with items[%d] at %s", j, item.Name)})
+			continue
+		}
+		rrsets[k] = i
+		names[name] = append(names[name], i)
+	}
+	for i := range c.Items {
+		item := &c.Items[i]
+		if item.RRType != TypeCNAME {
+			continue
+		}
+		name := dns.CanonicalName(item.Name)
+		if j, ok := rrsets[key{name, TypeCNAME}]; !ok || j != i {
+			continue
+		}
+		idx := names[name]
+		for _, j := range idx {
+			if j == i {
+				continue
+			}
+			errs = append(errs, &FieldError{Field: fmt.Sprintf("items[%d].rrtype", j), Value: string(c.Items[j].RRType), Message: fmt.Sprintf("can not coexist with CNAME at %s (items[%d])", item.Name, i)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package zones_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+func fieldErrors(err error) zones.FieldErrors {
+	var errs zones.FieldErrors
+	ExpectWithOffset(1, errors.As(err, &errs)).To(BeTrue())
+	return errs
+}
+
+var _ = Describe("records validation", func() {
+	var (
+		r   zones.Record
+		err error
+	)
+	BeforeEach(func() {
+		r = zones.Record{
+			Name:   "www.example.jp.",
+			TTL:    300,
+			RRType: zones.TypeA,
+			RData:  zones.RecordRDATASlice{{Value: "192.168.0.1"}},
+		}
+	})
+	Context("Record.Validate", func() {
+		It("accepts valid rdata", func() {
+			valids := map[zones.Type][]string{
+				zones.TypeA:     {"192.168.0.1", "192.168.0.2"},
+				zones.TypeAAAA:  {"2001:db8::1"},
+				zones.TypeCAA:   {`0 issue "letsencrypt.org"`},
+				zones.TypeCNAME: {"example.jp."},
+				zones.TypeANAME: {"example.net."},
+				zones.TypeMX:    {"10 mx.example.jp."},
+				zones.TypeNS:    {"ns.example.jp."},
+				zones.TypeNAPTR: {`100 10 "S" "SIP+D2U" "" _sip._udp.example.jp.`},
+				zones.TypeSRV:   {"1 2 5060 sip.example.jp."},
+				zones.TypeTXT: {
+					`"v=spf1 -all"`, "hoge",
+					`"v=DMARC1; p=none; rua=mailto:dmarc@example.jp"`,
+					`"v=DKIM1; k=rsa; " "p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC"`,
+					`"escaped \"; quote"`,
+					"v=spf1 include:example.net -all", "v=DMARC1; p=none",
+				},
+				zones.TypeTLSA:  {"3 1 1 0C72AC70B745AC19998811B131D662C9AC69DBDBE7CB23E5B514B56664C5D3D6"},
+				zones.TypePTR:   {"www.example.jp."},
+				zones.TypeDS:    {"60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118"},
+				zones.TypeSVCB:  {`1 . alpn="h2,h3"`},
+				zones.TypeHTTPS: {"1 . port=8443"},
+			}
+			for rrtype, values := range valids {
+				r.RRType = rrtype
+				r.RData = nil
+				for _, v := range values {
+					r.RData = append(r.RData, zones.RecordRDATA{Value: v})
+				}
+				Expect(r.Validate()).To(Succeed(), string(rrtype))
+			}
+		})
+		It("returns error for invalid rdata", func() {
+			invalids := []zones.Record{
+				{RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "2001:db8::1"}}},
+				{RRType: zones.TypeAAAA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+				{RRType: zones.TypeCAA, RData: zones.RecordRDATASlice{{Value: "issue letsencrypt.org"}}},
+				{RRType: zones.TypeMX, RData: zones.RecordRDATASlice{{Value: "mx.example.jp."}}},
+				{RRType: zones.TypeSRV, RData: zones.RecordRDATASlice{{Value: "1 2 sip.example.jp."}}},
+				{RRType: zones.TypeTLSA, RData: zones.RecordRDATASlice{{Value: "3 1 1 XYZ"}}},
+				{RRType: zones.TypeHTTPS, RData: zones.RecordRDATASlice{{Value: "1 . hoge"}}},
+				{RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: ""}}},
+				{RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1 ; comment"}}},
+				{RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `"v=DMARC1; p=none" ; comment`}}},
+				{RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1\n192.168.0.2"}}},
+			}
+			for _, invalid := range invalids {
+				r.RRType = invalid.RRType
+				r.RData = invalid.RData
+				errs := fieldErrors(r.Validate())
+				Expect(errs).To(HaveLen(1), invalid.RData.String())
+				Expect(errs[0].Field).To(Equal("rdata[0].value"))
+				Expect(errs[0].Value).To(Equal(invalid.RData[0].Value))
+			}
+		})
+		It("returns error for parse error without line position", func() {
+			r.RData = zones.RecordRDATASlice{{Value: "hoge"}}
+			err = r.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix(`rdata[0].value "hoge": invalid A rdata: `))
+			Expect(err.Error()).NotTo(ContainSubstring("at line"))
+		})
+		It("returns error for duplicated rdata", func() {
+			r.RRType = zones.TypeAAAA
+			r.RData = zones.RecordRDATASlice{{Value: "2001:db8::1"}, {Value: "2001:0db8:0::1"}}
+			errs := fieldErrors(r.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("rdata[1].value"))
+		})
+		It("returns error for multiple CNAME rdata", func() {
+			r.RRType = zones.TypeCNAME
+			r.RData = zones.RecordRDATASlice{{Value: "a.example.jp."}, {Value: "b.example.jp."}}
+			errs := fieldErrors(r.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("rdata"))
+		})
+		It("returns error for empty rdata", func() {
+			r.RData = nil
+			errs := fieldErrors(r.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("rdata"))
+		})
+		It("returns error for unsupported type", func() {
+			r.RRType = "HINFO"
+			errs := fieldErrors(r.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("rrtype"))
+		})
+		It("checks name", func() {
+			names := map[string]bool{
+				"www.example.jp.":  true,
+				"*.example.jp.":    true,
+				"":                 false,
+				"www.example.jp":   false,
+				"www..example.jp.": false,
+			}
+			for name, valid := range names {
+				r.Name = name
+				if valid {
+					Expect(r.Validate()).To(Succeed(), name)
+				} else {
+					errs := fieldErrors(r.Validate())
+					Expect(errs).To(HaveLen(1), name)
+					Expect(errs[0].Field).To(Equal("name"))
+				}
+			}
+		})
+		It("checks ttl", func() {
+			ttls := map[int32]bool{
+				0:          true,
+				30:         true,
+				2147483647: true,
+				29:         false,
+				-1:         false,
+			}
+			for ttl, valid := range ttls {
+				r.TTL = types.NullablePositiveInt32(ttl)
+				if valid {
+					Expect(r.Validate()).To(Succeed(), fmt.Sprint(ttl))
+				} else {
+					errs := fieldErrors(r.Validate())
+					Expect(errs).To(HaveLen(1), fmt.Sprint(ttl))
+					Expect(errs[0].Field).To(Equal("ttl"))
+				}
+			}
+		})
+		It("returns multiple errors", func() {
+			r.Name = "www"
+			r.TTL = 1
+			r.RData = zones.RecordRDATASlice{{Value: "hoge"}}
+			errs := fieldErrors(r.Validate())
+			Expect(errs).To(HaveLen(3))
+			Expect(errs.Error()).To(MatchRegexp(`^name "www": .*, ttl "1": .*, rdata\[0\].value "hoge": .*`))
+		})
+	})
+	Context("RecordList.Validate", func() {
+		var list zones.RecordList
+		BeforeEach(func() {
+			list = zones.RecordList{Items: []zones.Record{
+				r,
+				{Name: "www.example.jp.", RRType: zones.TypeAAAA, RData: zones.RecordRDATASlice{{Value: "2001:db8::1"}}},
+				{Name: "ftp.example.jp.", RRType: zones.TypeCNAME, RData: zones.RecordRDATASlice{{Value: "www.example.jp."}}},
+			}}
+		})
+		It("returns nil for valid list", func() {
+			Expect(list.Validate()).To(Succeed())
+		})
+		It("returns errors of items with index", func() {
+			list.Items[1].RData[0].Value = "hoge"
+			errs := fieldErrors(list.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("items[1].rdata[0].value"))
+		})
+		It("returns error for duplicated RRset", func() {
+			list.Items = append(list.Items, zones.Record{Name: "WWW.example.jp.", RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.2"}}})
+			errs := fieldErrors(list.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("items[3].rrtype"))
+		})
+		It("returns error for CNAME and other data", func() {
+			list.Items = append(list.Items, zones.Record{Name: "ftp.example.jp.", RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: "hoge"}}})
+			errs := fieldErrors(list.Validate())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Field).To(Equal("items[3].rrtype"))
+			Expect(errs[0].Message).To(ContainSubstring("CNAME"))
+		})
+		It("ignores records to be deleted", func() {
+			list.Items = append(list.Items, zones.Record{Name: "ftp.example.jp.", RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: "hoge"}}, State: zones.RecordStateToBeDeleted})
+			Expect(list.Validate()).To(Succeed())
+		})
+	})
+})
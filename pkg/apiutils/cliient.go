@@ -6,49 +6,61 @@ import (
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/tracing"
 )
 
 func SyncUpdate(ctx context.Context, cl api.ClientInterface, s api.Spec, body interface{}) (string, *core.Job, error) {
-	requestID, err := cl.Update(ctx, s, body)
-	if err != nil {
-		return requestID, nil, err
-	}
-	job, err := WaitJob(ctx, cl, requestID, time.Second)
-	return requestID, job, err
+	return syncDo(ctx, cl, nil, "SyncUpdate", s, func(ctx context.Context) (string, error) {
+		return cl.Update(ctx, s, body)
+	})
 }
 
 func SyncCreate(ctx context.Context, cl api.ClientInterface, s api.Spec, body interface{}) (string, *core.Job, error) {
-	requestID, err := cl.Create(ctx, s, body)
-	if err != nil {
-		return requestID, nil, err
-	}
-	job, err := WaitJob(ctx, cl, requestID, time.Second)
-	return requestID, job, err
+	return syncDo(ctx, cl, nil, "SyncCreate", s, func(ctx context.Context) (string, error) {
+		return cl.Create(ctx, s, body)
+	})
 }
 
 func SyncApply(ctx context.Context, cl api.ClientInterface, s api.Spec, body interface{}) (string, *core.Job, error) {
-	requestID, err := cl.Apply(ctx, s, body)
-	if err != nil {
-		return requestID, nil, err
-	}
-	job, err := WaitJob(ctx, cl, requestID, time.Second)
-	return requestID, job, err
+	return syncDo(ctx, cl, nil, "SyncApply", s, func(ctx context.Context) (string, error) {
+		return cl.Apply(ctx, s, body)
+	})
 }
 
 func SyncDelete(ctx context.Context, cl api.ClientInterface, s api.Spec) (string, *core.Job, error) {
-	requestID, err := cl.Delete(ctx, s)
-	if err != nil {
-		return requestID, nil, err
-	}
-	job, err := WaitJob(ctx, cl, requestID, time.Second)
-	return requestID, job, err
+	return syncDo(ctx, cl, nil, "SyncDelete", s, func(ctx context.Context) (string, error) {
+		return cl.Delete(ctx, s)
+	})
 }
 
 func SyncCancel(ctx context.Context, cl api.ClientInterface, s api.Spec) (string, *core.Job, error) {
-	requestID, err := cl.Cancel(ctx, s)
+	return syncDo(ctx, cl, nil, "SyncCancel", s, func(ctx context.Context) (string, error) {
+		return cl.Cancel(ctx, s)
+	})
+}
+
+// syncDo requests by do and waits the job by w.
+// If w is nil, WaitJob with 1s interval is used.
+// If ctx has tracer, it starts span `dpf.<name>` which is parent of the request and WaitJob.
+func syncDo(ctx context.Context, cl api.ClientInterface, w *JobWaiter, name string, s api.Spec, do func(ctx context.Context) (string, error)) (string, *core.Job, error) {
+	ctx, span := tracing.Start(ctx, name, tracing.SpecAttributes(s)...)
+	defer span.End()
+	requestID, err := do(ctx)
+	if requestID != "" {
+		span.SetAttributes(tracing.Attr(tracing.AttributeRequestID, requestID))
+	}
 	if err != nil {
+		span.RecordError(err)
 		return requestID, nil, err
 	}
-	job, err := WaitJob(ctx, cl, requestID, time.Second)
+	var job *core.Job
+	if w == nil {
+		job, err = WaitJob(ctx, cl, requestID, time.Second)
+	} else {
+		job, err = w.Wait(ctx, cl, requestID)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
 	return requestID, job, err
 }
@@ -4,37 +4,94 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"os"
-	"os/signal"
 	"path"
 	"strconv"
 	"time"
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/tracing"
 )
 
+// JobObserver is called when WaitJob returns.
+// job is nil, when WaitJob failed to read the job.
+type JobObserver func(ctx context.Context, jobID string, job *core.Job, wait time.Duration, err error)
+
+type jobObserverKey struct{}
+
+// ContextWithJobObserver returns ctx which has JobObserver.
+// If ctx already has JobObserver, both are called.
+func ContextWithJobObserver(ctx context.Context, observer JobObserver) context.Context {
+	if prev := jobObserverFromContext(ctx); prev != nil {
+		next := observer
+		observer = func(ctx context.Context, jobID string, job *core.Job, wait time.Duration, err error) {
+			prev(ctx, jobID, job, wait, err)
+			next(ctx, jobID, job, wait, err)
+		}
+	}
+	return context.WithValue(ctx, jobObserverKey{}, observer)
+}
+
+func jobObserverFromContext(ctx context.Context) JobObserver {
+	observer, _ := ctx.Value(jobObserverKey{}).(JobObserver)
+	return observer
+}
+
+// WaitJob waits until the job is finished by WatchRead with fixed interval.
+// It doesn't handle process signals, cancel ctx to stop waiting.
+// Use JobWaiter for backoff and timeout.
 func WaitJob(ctx context.Context, c api.ClientInterface, jobID string, interval time.Duration) (*core.Job, error) {
+	return observeWait(ctx, jobID, func(ctx context.Context) (*core.Job, error) {
+		return waitJob(ctx, c, jobID, interval)
+	})
+}
+
+// observeWait calls wait with span `dpf.WaitJob` and JobObserver of ctx.
+func observeWait(ctx context.Context, jobID string, wait func(ctx context.Context) (*core.Job, error)) (*core.Job, error) {
+	ctx, span := tracing.Start(ctx, "WaitJob", tracing.Attr(tracing.AttributeJobID, jobID))
+	defer span.End()
+	start := time.Now()
+	job, err := wait(ctx)
+	if job != nil {
+		span.SetAttributes(tracing.Attr(tracing.AttributeJobStatus, string(job.Status)))
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	if observer := jobObserverFromContext(ctx); observer != nil {
+		observer(ctx, jobID, job, time.Since(start), err)
+	}
+	return job, err
+}
+
+func waitJob(ctx context.Context, c api.ClientInterface, jobID string, interval time.Duration) (*core.Job, error) {
 	job := &core.Job{
 		RequestID: jobID,
 	}
 	if _, err := c.Read(ctx, job); err != nil {
 		return nil, fmt.Errorf("failed to read Job: %w", err)
 	}
-	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
-	defer stop()
 	for job.Status == core.JobStatusRunning {
 		job.RequestID = jobID
 		if err := c.WatchRead(ctx, interval, job); err != nil {
 			return nil, err
 		}
 	}
+	return jobResult(jobID, job)
+}
+
+func jobResult(jobID string, job *core.Job) (*core.Job, error) {
 	if job.Status == core.JobStatusFailed {
-		return job, fmt.Errorf("JobID %s job failed: type: %s msg: %s", jobID, job.ErrorType, job.ErrorMessage)
+		return job, jobFailedError(jobID, job)
 	}
 	return job, nil
 }
 
+// jobFailedError returns error of the failed job, it is also used by JobError.
+func jobFailedError(jobID string, job *core.Job) error {
+	return fmt.Errorf("JobID %s job failed: type: %s msg: %s", jobID, job.ErrorType, job.ErrorMessage)
+}
+
 func ParseeResourceSystemID(job *core.Job) (string, error) {
 	u, err := url.Parse(job.ResourceUrl)
 	if err != nil {
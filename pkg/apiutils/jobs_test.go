@@ -91,6 +91,31 @@ var _ = Describe("jobs", func() {
 			})
 		})
 	})
+	Context("ContextWithJobObserver", func() {
+		var calls []string
+		BeforeEach(func() {
+			calls = nil
+			c.ReadFunc = func(s api.Spec) (requestId string, err error) {
+				job := s.(*core.Job)
+				job.Status = core.JobStatusSuccessful
+				return "ok", nil
+			}
+			ctx := apiutils.ContextWithJobObserver(context.Background(), func(ctx context.Context, jobID string, job *core.Job, wait time.Duration, err error) {
+				calls = append(calls, "first "+jobID+" "+string(job.Status))
+			})
+			ctx = apiutils.ContextWithJobObserver(ctx, func(ctx context.Context, jobID string, job *core.Job, wait time.Duration, err error) {
+				calls = append(calls, "second "+jobID)
+			})
+			_, err = apiutils.WaitJob(ctx, c, "9BCFE2E9C10D4D9A8444CB0B48C72830", time.Second)
+		})
+		It("calls observers", func() {
+			Expect(err).To(Succeed())
+			Expect(calls).To(Equal([]string{
+				"first 9BCFE2E9C10D4D9A8444CB0B48C72830 SUCCESSFUL",
+				"second 9BCFE2E9C10D4D9A8444CB0B48C72830",
+			}))
+		})
+	})
 	Context("ParseeResourceSystemID", func() {
 		var (
 			job *core.Job
@@ -0,0 +1,79 @@
+package apiutils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+)
+
+// SetResourceID sets path params of s (e.g. Record.ID, Tsig.ID, Site.ResourceName)
+// from ResourceUrl of the finished job.
+// The path params are found so that read path of s matches ResourceUrl.
+func SetResourceID(s apis.Spec, job *core.Job) error {
+	if job == nil || job.ResourceUrl == "" {
+		return fmt.Errorf("job doesn't have resource url")
+	}
+	u, err := url.Parse(job.ResourceUrl)
+	if err != nil {
+		return fmt.Errorf("failed to parse resource-url: %s , %w", job.ResourceUrl, err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for n := 1; n <= apis.MaxPathParams && 2*n-1 <= len(segments); n++ {
+		// path params are every other segment from the last.
+		values := make([]string, n)
+		for i := range values {
+			values[i] = segments[len(segments)-1-2*(n-1-i)]
+		}
+		if args := matchPathParams(s, u.Path, values); args != nil {
+			return s.SetPathParams(args...)
+		}
+	}
+	return fmt.Errorf("resource-url %s doesn't match %s", job.ResourceUrl, s.GetName())
+}
+
+// matchPathParams returns args of SetPathParams, which makes read path of s be suffix of resourcePath.
+func matchPathParams(s apis.Spec, resourcePath string, values []string) []interface{} {
+	for _, args := range apis.PathParamsCandidates(values) {
+		c, ok := api.DeepCopySpec(s).(apis.Spec)
+		if !ok || c.SetPathParams(args...) != nil {
+			continue
+		}
+		if _, p := c.GetPathMethod(api.ActionRead); p != "" && strings.HasSuffix(resourcePath, p) {
+			return args
+		}
+	}
+	return nil
+}
+
+// ReadJobResource sets path params of s from the finished job, and reads s.
+//
+//	_, job, err := apiutils.SyncCreate(ctx, cl, record, nil)
+//	_, err = apiutils.ReadJobResource(ctx, cl, record, job)
+//	// record.ID is set, and record has server state
+func ReadJobResource(ctx context.Context, cl api.ClientInterface, s apis.Spec, job *core.Job) (string, error) {
+	if err := SetResourceID(s, job); err != nil {
+		return "", err
+	}
+	requestID, err := cl.Read(ctx, s)
+	if err != nil {
+		return requestID, fmt.Errorf("failed to read %s: %w", s.GetName(), err)
+	}
+	return requestID, nil
+}
+
+// SyncCreateAndRead creates s, waits the job and reads s.
+func SyncCreateAndRead(ctx context.Context, cl api.ClientInterface, s apis.Spec, body interface{}) (string, *core.Job, error) {
+	requestID, job, err := SyncCreate(ctx, cl, s, body)
+	if err != nil {
+		return requestID, job, err
+	}
+	if _, err := ReadJobResource(ctx, cl, s, job); err != nil {
+		return requestID, job, err
+	}
+	return requestID, job, nil
+}
@@ -0,0 +1,108 @@
+package apiutils_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+var _ = Describe("resource", func() {
+	Context("SetResourceID", func() {
+		job := func(path string) *core.Job {
+			return &core.Job{Status: core.JobStatusSuccessful, ResourceUrl: "https://api.dns-platform.jp/dpf/v1" + path}
+		}
+		It("sets id of specs", func() {
+			record := &zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			Expect(apiutils.SetResourceID(record, job("/zones/m1/records/r1"))).To(Succeed())
+			Expect(record.ID).To(Equal("r1"))
+
+			tsig := &contracts.Tsig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}}
+			Expect(apiutils.SetResourceID(tsig, job("/contracts/f1/tsigs/10"))).To(Succeed())
+			Expect(tsig.ID).To(Equal(int64(10)))
+
+			cc := &common_configs.CcPrimary{AttributeMeta: common_configs.AttributeMeta{CommonConfigID: 5}}
+			Expect(apiutils.SetResourceID(cc, job("/common_configs/5/cc_primaries/3"))).To(Succeed())
+			Expect(cc.ID).To(Equal(int64(3)))
+
+			site := &lb_domains.Site{AttributeMeta: lb_domains.AttributeMeta{LBDomainID: "b1"}}
+			Expect(apiutils.SetResourceID(site, job("/lb_domains/b1/sites/100"))).To(Succeed())
+			Expect(site.ResourceName).To(Equal("100"))
+
+			endpoint := &lb_domains.Endpoint{SiteAttributeMeta: lb_domains.SiteAttributeMeta{AttributeMeta: lb_domains.AttributeMeta{LBDomainID: "b1"}, SiteResourceName: "s1"}}
+			Expect(apiutils.SetResourceID(endpoint, job("/lb_domains/b1/sites/s1/endpoints/e1"))).To(Succeed())
+			Expect(endpoint.ResourceName).To(Equal("e1"))
+		})
+		It("returns error, if job doesn't have resource url", func() {
+			Expect(apiutils.SetResourceID(&zones.Record{}, &core.Job{})).To(HaveOccurred())
+			Expect(apiutils.SetResourceID(&zones.Record{}, nil)).To(HaveOccurred())
+		})
+		It("returns error, if resource url doesn't match spec", func() {
+			record := &zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			Expect(apiutils.SetResourceID(record, job("/contracts/f1/tsigs/10"))).To(HaveOccurred())
+			Expect(record.ID).To(BeEmpty())
+		})
+	})
+	Context("SyncCreateAndRead", func() {
+		var (
+			fs  *testtool.FakeServer
+			srv *httptest.Server
+			cl  *api.Client
+			err error
+		)
+		BeforeEach(func() {
+			fs = testtool.NewFakeServer()
+			fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."})
+			fs.AddContract(core.Contract{ID: "f1"}, contracts.CommonConfig{ID: 1, Name: "default"})
+			srv = httptest.NewServer(fs)
+			cl = api.NewClient("token", srv.URL, nil)
+			cl.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+		})
+		AfterEach(func() {
+			srv.Close()
+		})
+		It("returns spec which has server state", func() {
+			specs := []apis.Spec{
+				&zones.Record{
+					AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+					Name:          "www.example.jp.",
+					TTL:           300,
+					RRType:        zones.TypeA,
+					RData:         zones.RecordRDATASlice{{Value: "192.168.0.1"}},
+				},
+				&contracts.CommonConfig{
+					AttributeMeta: contracts.AttributeMeta{ContractID: "f1"},
+					Name:          "new",
+				},
+			}
+			for _, s := range specs {
+				_, _, err = apiutils.SyncCreateAndRead(context.Background(), cl, s, nil)
+				Expect(err).To(Succeed())
+			}
+			record := specs[0].(*zones.Record)
+			Expect(record.ID).NotTo(BeEmpty())
+			Expect(record.State).To(Equal(zones.RecordStateToBeAdded))
+			cc := specs[1].(*contracts.CommonConfig)
+			Expect(cc.ID).NotTo(BeZero())
+			Expect(cc.Default).To(Equal(types.Disabled))
+		})
+		It("returns error, if create is failed", func() {
+			_, _, err = apiutils.SyncCreateAndRead(context.Background(), cl, &zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,196 @@
+package apiutils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+)
+
+// DefaultJobTrackerConcurrency is the default number of jobs which are polled at the same time.
+const DefaultJobTrackerConcurrency = 10
+
+// JobResult is result of a job tracked by JobTracker.
+type JobResult struct {
+	JobID string
+	// Job is nil, when JobTracker failed to read the job.
+	Job *core.Job
+	// ResourceSystemID is the last path segment of ResourceUrl of successful job.
+	ResourceSystemID string
+	// ResourceID is ResourceSystemID as int64, it is 0 if the id is not numeric.
+	ResourceID int64
+	Err        error
+}
+
+// JobError is error of a job tracked by JobTracker.
+type JobError struct {
+	JobID string
+	// Job is nil, when JobTracker failed to read the job.
+	Job *core.Job
+	Err error
+}
+
+func (e *JobError) Error() string {
+	if e.Job != nil && e.Job.Status == core.JobStatusFailed {
+		return jobFailedError(e.JobID, e.Job).Error()
+	}
+	return fmt.Sprintf("JobID %s: %s", e.JobID, e.Err)
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+// JobErrors is aggregated errors of JobTracker.
+type JobErrors []*JobError
+
+func (e JobErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d jobs failed: %s", len(e), strings.Join(msgs, ", "))
+}
+
+// JobTracker waits many jobs concurrently.
+// All polls are sent by Client, so they share rate limit of the client.
+//
+//	tr := apiutils.NewJobTracker(cl, nil)
+//	tr.Add(requestIDs...)
+//	results, err := tr.Wait(ctx)
+type JobTracker struct {
+	Client api.ClientInterface
+	// Waiter waits each job, DefaultJobWaiter is used if it is nil.
+	Waiter *JobWaiter
+	// Concurrency is the number of jobs which are polled at the same time.
+	Concurrency int
+
+	mu      sync.Mutex
+	jobIDs  []string
+	results []JobResult
+}
+
+func NewJobTracker(cl api.ClientInterface, w *JobWaiter) *JobTracker {
+	return &JobTracker{
+		Client:      cl,
+		Waiter:      w,
+		Concurrency: DefaultJobTrackerConcurrency,
+	}
+}
+
+// Add adds jobs to be tracked, duplicated job is ignored.
+func (t *JobTracker) Add(jobIDs ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, jobID := range jobIDs {
+		if !t.contains(jobID) {
+			t.jobIDs = append(t.jobIDs, jobID)
+		}
+	}
+}
+
+func (t *JobTracker) contains(jobID string) bool {
+	for _, id := range t.jobIDs {
+		if id == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// Run waits added jobs and sends the result of each job when it is finished.
+// The channel is closed when all jobs are finished, then Err returns aggregated errors.
+// The channel is buffered for all jobs, so the caller may stop receiving results.
+// Cancel ctx to stop waiting.
+func (t *JobTracker) Run(ctx context.Context) <-chan JobResult {
+	t.mu.Lock()
+	jobIDs := t.jobIDs
+	t.jobIDs = nil
+	t.results = make([]JobResult, len(jobIDs))
+	t.mu.Unlock()
+
+	w := t.Waiter
+	if w == nil {
+		w = DefaultJobWaiter()
+	}
+	workers := t.Concurrency
+	if workers < 1 {
+		workers = DefaultJobTrackerConcurrency
+	}
+	if workers > len(jobIDs) {
+		workers = len(jobIDs)
+	}
+	queue := make(chan int)
+	// buffered, workers don't block even if the caller stops receiving.
+	results := make(chan JobResult, len(jobIDs))
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				res := t.wait(ctx, w, jobIDs[i])
+				t.mu.Lock()
+				t.results[i] = res
+				t.mu.Unlock()
+				results <- res
+			}
+		}()
+	}
+	go func() {
+		for i := range jobIDs {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+func (t *JobTracker) wait(ctx context.Context, w *JobWaiter, jobID string) JobResult {
+	job, err := w.Wait(ctx, t.Client, jobID)
+	res := JobResult{JobID: jobID, Job: job, Err: err}
+	if err != nil || job == nil || job.ResourceUrl == "" {
+		return res
+	}
+	if res.ResourceSystemID, err = ParseeResourceSystemID(job); err != nil {
+		res.Err = err
+		return res
+	}
+	if id, err := ParseeResourceID(job); err == nil {
+		res.ResourceID = id
+	}
+	return res
+}
+
+// Err returns aggregated errors of the last Run in added order.
+// It returns nil, if all jobs are successful.
+func (t *JobTracker) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var errs JobErrors
+	for _, res := range t.results {
+		if res.Err != nil {
+			errs = append(errs, &JobError{JobID: res.JobID, Job: res.Job, Err: res.Err})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Wait waits added jobs and returns the results in added order.
+// If some jobs are failed, it returns JobErrors.
+func (t *JobTracker) Wait(ctx context.Context) ([]JobResult, error) {
+	for range t.Run(ctx) {
+	}
+	t.mu.Lock()
+	results := append([]JobResult{}, t.results...)
+	t.mu.Unlock()
+	return results, t.Err()
+}
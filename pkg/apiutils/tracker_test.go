@@ -0,0 +1,122 @@
+package apiutils_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("JobTracker", func() {
+	var (
+		c       *testtool.TestClient
+		tr      *apiutils.JobTracker
+		mu      sync.Mutex
+		reads   map[string]int
+		jobs    map[string]core.Job
+		results []apiutils.JobResult
+		err     error
+	)
+	BeforeEach(func() {
+		reads = map[string]int{}
+		jobs = map[string]core.Job{
+			"job1": {Status: core.JobStatusSuccessful, ResourceUrl: "https://api.dns-platform.jp/dpf/v1/zones/m1/records/r1"},
+			"job2": {Status: core.JobStatusFailed, ErrorType: "ParameterError", ErrorMessage: "Invalid."},
+			"job3": {Status: core.JobStatusSuccessful, ResourceUrl: "https://api.dns-platform.jp/dpf/v1/contracts/f1/common_configs/10"},
+		}
+		c = testtool.NewTestClient("token", "http://localhost", nil)
+		c.ReadFunc = func(s api.Spec) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			job := s.(*core.Job)
+			reads[job.RequestID]++
+			res, ok := jobs[job.RequestID]
+			if !ok {
+				return "", &api.BadResponse{StatusCode: 404, ErrorType: api.ErrorTypeNotFound}
+			}
+			// finished at the second read
+			if reads[job.RequestID] < 2 {
+				res = core.Job{Status: core.JobStatusRunning}
+			}
+			res.RequestID = job.RequestID
+			*job = res
+			return "ok", nil
+		}
+		tr = apiutils.NewJobTracker(c, &apiutils.JobWaiter{InitialInterval: time.Millisecond, Multiplier: 1})
+	})
+	When("all jobs are successful", func() {
+		BeforeEach(func() {
+			tr.Add("job1", "job3", "job1")
+			results, err = tr.Wait(context.Background())
+		})
+		It("returns results in added order", func() {
+			Expect(err).To(Succeed())
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].JobID).To(Equal("job1"))
+			Expect(results[0].Job.Status).To(Equal(core.JobStatusSuccessful))
+			Expect(results[1].JobID).To(Equal("job3"))
+		})
+		It("resolves resource ids", func() {
+			Expect(results[0].ResourceSystemID).To(Equal("r1"))
+			Expect(results[0].ResourceID).To(BeZero())
+			Expect(results[1].ResourceSystemID).To(Equal("10"))
+			Expect(results[1].ResourceID).To(Equal(int64(10)))
+		})
+		It("polls jobs until finished", func() {
+			Expect(reads).To(Equal(map[string]int{"job1": 2, "job3": 2}))
+		})
+	})
+	When("some jobs are failed", func() {
+		BeforeEach(func() {
+			tr.Add("job1", "job2", "job3", "job4")
+			results, err = tr.Wait(context.Background())
+		})
+		It("returns aggregated errors", func() {
+			Expect(err).To(HaveOccurred())
+			errs := apiutils.JobErrors{}
+			Expect(errors.As(err, &errs)).To(BeTrue())
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].JobID).To(Equal("job2"))
+			Expect(errs[0].Job.ErrorType).To(Equal("ParameterError"))
+			Expect(errs[0].Error()).To(Equal(errs[0].Err.Error()))
+			Expect(errs[1].JobID).To(Equal("job4"))
+			Expect(api.IsStatusCode(errs[1], 404)).To(BeTrue())
+			Expect(err.Error()).To(Equal("2 jobs failed: JobID job2 job failed: type: ParameterError msg: Invalid., JobID job4: failed to read Job: ErrorType: NotFound Message: "))
+		})
+		It("returns all results", func() {
+			Expect(results).To(HaveLen(4))
+			Expect(results[0].Err).To(Succeed())
+			Expect(results[2].Err).To(Succeed())
+		})
+	})
+	Context("Run", func() {
+		It("sends result of each job", func() {
+			tr.Concurrency = 1
+			tr.Add("job1", "job2")
+			ids := []string{}
+			for res := range tr.Run(context.Background()) {
+				ids = append(ids, res.JobID)
+			}
+			Expect(ids).To(Equal([]string{"job1", "job2"}))
+			Expect(tr.Err()).To(HaveOccurred())
+		})
+		It("doesn't block workers when the caller stops receiving", func() {
+			tr.Add("job1", "job2")
+			ch := tr.Run(context.Background())
+			Eventually(func() int { return len(ch) }).Should(Equal(2))
+			Expect(tr.Err()).To(HaveOccurred())
+		})
+		It("closes channel without jobs", func() {
+			Eventually(tr.Run(context.Background())).Should(BeClosed())
+			Expect(tr.Err()).To(Succeed())
+		})
+	})
+})
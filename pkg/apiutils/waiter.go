@@ -0,0 +1,124 @@
+package apiutils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+)
+
+// DefaultJobInterval is used as InitialInterval of JobWaiter when it is 0.
+const DefaultJobInterval = time.Second
+
+// JobWaiter waits jobs with exponential backoff.
+// It doesn't handle process signals, cancel ctx to stop waiting.
+type JobWaiter struct {
+	// wait time after the first read of the job, 0 is DefaultJobInterval
+	InitialInterval time.Duration
+	// max wait time of backoff
+	MaxInterval time.Duration
+	// backoff multiplier, 1.0 is fixed interval, less than 1.0 is regarded as 1.0
+	Multiplier float64
+	// overall deadline of waiting, 0 is no timeout
+	Timeout time.Duration
+	// Progress is called after each read of the job, if it is not nil.
+	Progress func(job *core.Job, elapsed time.Duration)
+}
+
+func DefaultJobWaiter() *JobWaiter {
+	return &JobWaiter{
+		InitialInterval: DefaultJobInterval,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2.0,
+	}
+}
+
+// Interval returns wait time after the attempt th read of the job.
+func (w *JobWaiter) Interval(attempt int) time.Duration {
+	initial := w.InitialInterval
+	if initial <= 0 {
+		initial = DefaultJobInterval
+	}
+	multiplier := w.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	interval := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if w.MaxInterval > 0 && interval > float64(w.MaxInterval) {
+		return w.MaxInterval
+	}
+	if interval > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(interval)
+}
+
+// Wait waits until the job is finished.
+// If the job is failed, it returns the job and error.
+func (w *JobWaiter) Wait(ctx context.Context, c api.ClientInterface, jobID string) (*core.Job, error) {
+	return observeWait(ctx, jobID, func(ctx context.Context) (*core.Job, error) {
+		return w.wait(ctx, c, jobID)
+	})
+}
+
+func (w *JobWaiter) wait(ctx context.Context, c api.ClientInterface, jobID string) (*core.Job, error) {
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	job := &core.Job{}
+	for attempt := 1; ; attempt++ {
+		job.RequestID = jobID
+		if _, err := c.Read(ctx, job); err != nil {
+			return nil, fmt.Errorf("failed to read Job: %w", err)
+		}
+		if w.Progress != nil {
+			w.Progress(job, time.Since(start))
+		}
+		if job.Status != core.JobStatusRunning {
+			return jobResult(jobID, job)
+		}
+		timer := time.NewTimer(w.Interval(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return job, fmt.Errorf("failed to wait JobID %s: %w", jobID, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (w *JobWaiter) SyncUpdate(ctx context.Context, cl api.ClientInterface, s api.Spec, body interface{}) (string, *core.Job, error) {
+	return syncDo(ctx, cl, w, "SyncUpdate", s, func(ctx context.Context) (string, error) {
+		return cl.Update(ctx, s, body)
+	})
+}
+
+func (w *JobWaiter) SyncCreate(ctx context.Context, cl api.ClientInterface, s api.Spec, body interface{}) (string, *core.Job, error) {
+	return syncDo(ctx, cl, w, "SyncCreate", s, func(ctx context.Context) (string, error) {
+		return cl.Create(ctx, s, body)
+	})
+}
+
+func (w *JobWaiter) SyncApply(ctx context.Context, cl api.ClientInterface, s api.Spec, body interface{}) (string, *core.Job, error) {
+	return syncDo(ctx, cl, w, "SyncApply", s, func(ctx context.Context) (string, error) {
+		return cl.Apply(ctx, s, body)
+	})
+}
+
+func (w *JobWaiter) SyncDelete(ctx context.Context, cl api.ClientInterface, s api.Spec) (string, *core.Job, error) {
+	return syncDo(ctx, cl, w, "SyncDelete", s, func(ctx context.Context) (string, error) {
+		return cl.Delete(ctx, s)
+	})
+}
+
+func (w *JobWaiter) SyncCancel(ctx context.Context, cl api.ClientInterface, s api.Spec) (string, *core.Job, error) {
+	return syncDo(ctx, cl, w, "SyncCancel", s, func(ctx context.Context) (string, error) {
+		return cl.Cancel(ctx, s)
+	})
+}
@@ -0,0 +1,153 @@
+package apiutils_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("JobWaiter", func() {
+	var (
+		c     *testtool.TestClient
+		w     *apiutils.JobWaiter
+		reads int
+		job   *core.Job
+		err   error
+	)
+	// job is finished at the n th read
+	finishAt := func(n int, status core.JobStatus) {
+		c.ReadFunc = func(s api.Spec) (string, error) {
+			reads++
+			job := s.(*core.Job)
+			job.Status = core.JobStatusRunning
+			if reads >= n {
+				job.Status = status
+			}
+			return "ok", nil
+		}
+	}
+	BeforeEach(func() {
+		reads = 0
+		c = testtool.NewTestClient("token", "http://localhost", nil)
+		w = &apiutils.JobWaiter{
+			InitialInterval: time.Millisecond,
+			MaxInterval:     4 * time.Millisecond,
+			Multiplier:      2.0,
+		}
+	})
+	Context("Interval", func() {
+		It("returns exponential backoff up to MaxInterval", func() {
+			Expect(w.Interval(1)).To(Equal(time.Millisecond))
+			Expect(w.Interval(2)).To(Equal(2 * time.Millisecond))
+			Expect(w.Interval(3)).To(Equal(4 * time.Millisecond))
+			Expect(w.Interval(10)).To(Equal(4 * time.Millisecond))
+		})
+		It("returns fixed interval with Multiplier 1", func() {
+			w.Multiplier = 1
+			Expect(w.Interval(5)).To(Equal(time.Millisecond))
+		})
+		It("regards Multiplier less than 1 as 1", func() {
+			w.Multiplier = 0
+			Expect(w.Interval(5)).To(Equal(time.Millisecond))
+		})
+		It("uses DefaultJobInterval when InitialInterval is 0", func() {
+			w.InitialInterval = 0
+			w.MaxInterval = 0
+			Expect(w.Interval(1)).To(Equal(apiutils.DefaultJobInterval))
+		})
+	})
+	Context("DefaultJobWaiter", func() {
+		It("has no timeout", func() {
+			Expect(apiutils.DefaultJobWaiter().Timeout).To(BeZero())
+			Expect(apiutils.DefaultJobWaiter().Interval(1)).To(Equal(time.Second))
+		})
+	})
+	Context("Wait", func() {
+		When("job successful", func() {
+			var elapsed []time.Duration
+			BeforeEach(func() {
+				elapsed = nil
+				finishAt(3, core.JobStatusSuccessful)
+				w.Progress = func(job *core.Job, d time.Duration) {
+					elapsed = append(elapsed, d)
+				}
+				job, err = w.Wait(context.Background(), c, "9BCFE2E9C10D4D9A8444CB0B48C72830")
+			})
+			It("returns job", func() {
+				Expect(err).To(Succeed())
+				Expect(job.Status).To(Equal(core.JobStatusSuccessful))
+				Expect(job.RequestID).To(Equal("9BCFE2E9C10D4D9A8444CB0B48C72830"))
+				Expect(reads).To(Equal(3))
+			})
+			It("calls progress for each read", func() {
+				Expect(elapsed).To(HaveLen(3))
+				Expect(elapsed[2]).To(BeNumerically(">=", 3*time.Millisecond))
+			})
+		})
+		When("job failed", func() {
+			BeforeEach(func() {
+				finishAt(2, core.JobStatusFailed)
+				job, err = w.Wait(context.Background(), c, "9BCFE2E9C10D4D9A8444CB0B48C72830")
+			})
+			It("returns job and error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(MatchRegexp("JobID 9BCFE2E9C10D4D9A8444CB0B48C72830 job failed"))
+				Expect(job.Status).To(Equal(core.JobStatusFailed))
+			})
+		})
+		When("failed to read", func() {
+			BeforeEach(func() {
+				job, err = w.Wait(context.Background(), c, "9BCFE2E9C10D4D9A8444CB0B48C72830")
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(MatchRegexp("failed to read Job"))
+			})
+		})
+		When("timeout", func() {
+			BeforeEach(func() {
+				finishAt(1000000, core.JobStatusSuccessful)
+				w.Timeout = 20 * time.Millisecond
+				job, err = w.Wait(context.Background(), c, "9BCFE2E9C10D4D9A8444CB0B48C72830")
+			})
+			It("returns deadline error", func() {
+				Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+				Expect(job.Status).To(Equal(core.JobStatusRunning))
+			})
+		})
+		When("ctx is canceled", func() {
+			BeforeEach(func() {
+				finishAt(1000000, core.JobStatusSuccessful)
+				ctx, cancel := context.WithCancel(context.Background())
+				w.Progress = func(*core.Job, time.Duration) { cancel() }
+				job, err = w.Wait(ctx, c, "9BCFE2E9C10D4D9A8444CB0B48C72830")
+			})
+			It("returns canceled error", func() {
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+				Expect(reads).To(Equal(1))
+			})
+		})
+	})
+	Context("SyncCreate", func() {
+		BeforeEach(func() {
+			finishAt(2, core.JobStatusSuccessful)
+			c.CreateFunc = func(s api.Spec, body interface{}) (string, error) {
+				return "9BCFE2E9C10D4D9A8444CB0B48C72830", nil
+			}
+			_, job, err = w.SyncCreate(context.Background(), c, &testtool.TestSpec{}, nil)
+		})
+		It("waits job by the waiter", func() {
+			Expect(err).To(Succeed())
+			Expect(job.Status).To(Equal(core.JobStatusSuccessful))
+			Expect(reads).To(Equal(2))
+		})
+	})
+})
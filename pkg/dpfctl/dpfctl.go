@@ -0,0 +1,414 @@
+// Package dpfctl implements dpfctl command.
+// Kinds of schema.SchemaSet are resolved by name, and path params are given as arguments.
+//
+//	dpfctl get Record m1 r1
+//	dpfctl list Record m1 -o yaml
+//	dpfctl create -f record.json -wait
+//	dpfctl wait-job <request id>
+//	dpfctl schema Record -action create
+package dpfctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/manifest"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+
+	// register all kinds.
+	_ "github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	_ "github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	_ "github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	_ "github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	_ "github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+)
+
+// Environment variables of default options.
+const (
+	EnvToken    = "DPF_TOKEN"
+	EnvEndpoint = "DPF_ENDPOINT"
+)
+
+// ErrUsage is returned when arguments are invalid.
+var ErrUsage = errors.New("invalid usage")
+
+const usage = `Usage: dpfctl <command> [options] [kind] [path params...]
+
+Commands:
+  get       read a resource
+  list      list resources, kind may be the kind of the items (e.g. Record)
+  create    create a resource
+  update    update a resource
+  apply     apply a resource
+  delete    delete a resource
+  cancel    cancel changes of a resource
+  wait-job  wait a job: dpfctl wait-job <request id>
+  schema    print JSON Schema of manifests, or of request body by -action: dpfctl schema [kind]
+
+Kind is resolved case-insensitively, use -api-version when the kind exists in some groups.
+When -f is given, kind is read from the json or yaml file, and arguments are path params.
+
+Options:
+`
+
+// Command is dpfctl command.
+type Command struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(string) string
+	// NewClient creates client, api.NewClient is used if it is nil.
+	NewClient func(token, endpoint string) api.ClientInterface
+	// Waiter waits jobs, DefaultJobWaiter is used if it is nil.
+	Waiter *apiutils.JobWaiter
+}
+
+func New() *Command {
+	return &Command{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Getenv: os.Getenv,
+	}
+}
+
+// Main runs dpfctl by args without command name, and returns exit code.
+func Main(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := New()
+	if err := c.Run(ctx, args); err != nil {
+		if !errors.Is(err, ErrUsage) {
+			fmt.Fprintf(c.Stderr, "error: %s\n", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+type options struct {
+	token      string
+	endpoint   string
+	output     string
+	apiVersion string
+	file       string
+	query      string
+	action     string
+	wait       bool
+	timeout    time.Duration
+}
+
+func (c *Command) getenv(key string) string {
+	if c.Getenv == nil {
+		return ""
+	}
+	return c.Getenv(key)
+}
+
+func (c *Command) flagSet(verb string, o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("dpfctl "+verb, flag.ContinueOnError)
+	fs.SetOutput(c.Stderr)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&o.token, "token", c.getenv(EnvToken), "API token (default $"+EnvToken+")")
+	fs.StringVar(&o.endpoint, "endpoint", c.getenv(EnvEndpoint), "API endpoint (default $"+EnvEndpoint+")")
+	fs.StringVar(&o.output, "o", outputTable, "output format: table, json or yaml")
+	fs.StringVar(&o.apiVersion, "api-version", "", "apiVersion of kind")
+	fs.DurationVar(&o.timeout, "timeout", 0, "timeout of waiting job, 0 is no timeout")
+	switch verb {
+	case "list":
+		fs.StringVar(&o.query, "query", "", "search params as query string (e.g. `name=www&limit=10`)")
+	case "schema":
+		fs.StringVar(&o.action, "action", "", "print request body of the action: create, update or apply")
+	case "get", "wait-job":
+	default:
+		fs.StringVar(&o.file, "f", "", "read resource from the file, `-` is stdin")
+		fs.BoolVar(&o.wait, "wait", false, "wait the job, and print the result")
+	}
+	return fs
+}
+
+// parseArgs parses flags which may be placed after arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// Run runs dpfctl by args without command name.
+func (c *Command) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(c.Stderr, usage)
+		return ErrUsage
+	}
+	verb := args[0]
+	switch verb {
+	case "help", "-h", "-help", "--help":
+		o := &options{}
+		fs := c.flagSet("help", o)
+		fs.SetOutput(c.Stdout)
+		fs.Usage()
+		return nil
+	case "get", "list", "create", "update", "apply", "delete", "cancel", "wait-job", "schema":
+	default:
+		fmt.Fprintf(c.Stderr, "unknown command `%s`\n\n%s", verb, usage)
+		return ErrUsage
+	}
+	o := &options{}
+	fs := c.flagSet(verb, o)
+	args, err := parseArgs(fs, args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return ErrUsage
+	}
+	if verb == "schema" {
+		return c.jsonSchema(o, args)
+	}
+	switch o.output {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return fmt.Errorf("unknown output format `%s`", o.output)
+	}
+	if o.token == "" {
+		return fmt.Errorf("token is required, set -token or %s", EnvToken)
+	}
+	cl := c.client(o)
+	w := c.waiter(o)
+
+	if verb == "wait-job" {
+		if len(args) != 1 {
+			return fmt.Errorf("wait-job needs a request id")
+		}
+		job, err := w.Wait(ctx, cl, args[0])
+		if err != nil {
+			return err
+		}
+		return c.print(o, job)
+	}
+
+	s, err := c.spec(o, args, verb == "list")
+	if err != nil {
+		return err
+	}
+	switch verb {
+	case "get":
+		if _, err := cl.Read(ctx, s); err != nil {
+			return err
+		}
+		return c.print(o, s)
+	case "list":
+		return c.list(ctx, cl, o, s)
+	case "create":
+		if o.wait {
+			_, job, err := w.SyncCreate(ctx, cl, s, nil)
+			if err != nil {
+				return err
+			}
+			if _, err := apiutils.ReadJobResource(ctx, cl, s, job); err != nil {
+				return err
+			}
+			return c.print(o, s)
+		}
+		return c.async(ctx, cl, o, func() (string, error) { return cl.Create(ctx, s, nil) })
+	case "update":
+		return c.async(ctx, cl, o, func() (string, error) { return cl.Update(ctx, s, nil) })
+	case "apply":
+		return c.async(ctx, cl, o, func() (string, error) { return cl.Apply(ctx, s, nil) })
+	case "delete":
+		return c.async(ctx, cl, o, func() (string, error) { return cl.Delete(ctx, s) })
+	}
+	return c.async(ctx, cl, o, func() (string, error) { return cl.Cancel(ctx, s) })
+}
+
+// jsonSchema prints JSON Schema of all manifests, manifest of kind, or request body of -action.
+func (c *Command) jsonSchema(o *options, args []string) error {
+	var (
+		res    *schema.JSONSchema
+		s      apis.Spec
+		action api.Action
+		err    error
+	)
+	switch {
+	case len(args) == 0 && o.action == "":
+		res = manifest.JSONSchemas()
+	case len(args) != 1:
+		return fmt.Errorf("schema needs a kind")
+	default:
+		if s, err = schema.SchemaSet.Lookup(o.apiVersion, args[0]); err != nil {
+			return err
+		}
+		if o.action == "" {
+			res, err = manifest.JSONSchema(s.GetGroup(), schema.KindName(s))
+		} else if action, err = writableAction(o.action); err == nil {
+			res, err = schema.SchemaSet.BodyJSONSchema(s.GetGroup(), schema.KindName(s), action)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	enc := json.NewEncoder(c.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func writableAction(name string) (api.Action, error) {
+	for _, action := range schema.WritableActions {
+		if strings.EqualFold(string(action), name) {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("unknown action `%s`, action must be create, update or apply", name)
+}
+
+func (c *Command) client(o *options) api.ClientInterface {
+	if c.NewClient != nil {
+		return c.NewClient(o.token, o.endpoint)
+	}
+	if o.endpoint == "" {
+		return api.New(o.token)
+	}
+	return api.NewClient(o.token, o.endpoint, nil)
+}
+
+func (c *Command) waiter(o *options) *apiutils.JobWaiter {
+	w := apiutils.DefaultJobWaiter()
+	if c.Waiter != nil {
+		*w = *c.Waiter
+	}
+	if o.timeout > 0 {
+		w.Timeout = o.timeout
+	}
+	return w
+}
+
+// spec returns spec from the file or kind name, and sets path params.
+func (c *Command) spec(o *options, args []string, list bool) (apis.Spec, error) {
+	var (
+		s   apis.Spec
+		err error
+	)
+	if o.file != "" {
+		bs, err := readFile(o.file)
+		if err != nil {
+			return nil, err
+		}
+		if s, err = schema.SchemaSet.ParseYAML(bs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", o.file, err)
+		}
+	} else {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("kind is required")
+		}
+		if s, err = schema.SchemaSet.Lookup(o.apiVersion, args[0]); err != nil && !list {
+			return nil, err
+		}
+		if _, ok := s.(api.ListSpec); list && !ok {
+			if ls, lerr := schema.SchemaSet.Lookup(o.apiVersion, args[0]+"List"); lerr == nil {
+				s, err = ls, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = args[1:]
+	}
+	if err := setPathParams(s, args); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func readFile(name string) ([]byte, error) {
+	var (
+		bs  []byte
+		err error
+	)
+	if name == "-" {
+		bs, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		bs, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return bs, nil
+}
+
+// setPathParams sets path params of s, numeric arguments are tried as both string and int64.
+func setPathParams(s apis.Spec, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	var err error
+	for _, params := range apis.PathParamsCandidates(args) {
+		if err = s.SetPathParams(params...); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to set path params: %w", err)
+}
+
+func (c *Command) list(ctx context.Context, cl api.ClientInterface, o *options, s apis.Spec) error {
+	ls, ok := s.(api.ListSpec)
+	if !ok {
+		return fmt.Errorf("kind `%s` is not list", schema.KindName(s))
+	}
+	var keywords api.SearchParams
+	if o.query != "" {
+		params, err := api.NewRowSearchParams(o.query)
+		if err != nil {
+			return fmt.Errorf("failed to parse query: %w", err)
+		}
+		keywords = params
+	}
+	var err error
+	if cs, ok := ls.(api.CountableListSpec); ok {
+		_, err = cl.ListAll(ctx, cs, keywords)
+	} else {
+		_, err = cl.List(ctx, ls, keywords)
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(o, ls)
+}
+
+// async prints the request id, or waits the job and prints it if -wait is given.
+func (c *Command) async(ctx context.Context, cl api.ClientInterface, o *options, do func() (string, error)) error {
+	requestID, err := do()
+	if err != nil {
+		return err
+	}
+	if !o.wait {
+		_, err := fmt.Fprintln(c.Stdout, requestID)
+		return err
+	}
+	job, err := c.waiter(o).Wait(ctx, cl, requestID)
+	if err != nil {
+		return err
+	}
+	return c.print(o, job)
+}
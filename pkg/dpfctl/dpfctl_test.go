@@ -0,0 +1,246 @@
+package dpfctl_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/dpfctl"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+)
+
+var _ = Describe("Command", func() {
+	var (
+		fs     *testtool.FakeServer
+		srv    *httptest.Server
+		cmd    *dpfctl.Command
+		env    map[string]string
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+		dir    string
+		err    error
+	)
+	run := func(args ...string) error {
+		stdout.Reset()
+		stderr.Reset()
+		return cmd.Run(context.Background(), args)
+	}
+	writeManifest := func(s api.Spec) string {
+		bs, err := api.MarshalOutput(s)
+		Expect(err).To(Succeed())
+		name := filepath.Join(dir, "manifest.json")
+		Expect(ioutil.WriteFile(name, bs, 0o600)).To(Succeed())
+		return name
+	}
+	BeforeEach(func() {
+		fs = testtool.NewFakeServer()
+		fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."},
+			zones.Record{ID: "r1", Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+			zones.Record{ID: "r2", Name: "mail.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.2"}}},
+		)
+		fs.AddContract(core.Contract{ID: "f1"}, contracts.CommonConfig{ID: 1, Name: "default"})
+		srv = httptest.NewServer(fs)
+		env = map[string]string{dpfctl.EnvToken: "token", dpfctl.EnvEndpoint: srv.URL}
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+		cmd = &dpfctl.Command{
+			Stdout: stdout,
+			Stderr: stderr,
+			Getenv: func(key string) string { return env[key] },
+			NewClient: func(token, endpoint string) api.ClientInterface {
+				cl := api.NewClient(token, endpoint, nil)
+				cl.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+				return cl
+			},
+			Waiter: &apiutils.JobWaiter{InitialInterval: 10 * time.Millisecond, Multiplier: 1.0},
+		}
+		dir, err = ioutil.TempDir("", "dpfctl")
+		Expect(err).To(Succeed())
+	})
+	AfterEach(func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	})
+	Context("usage", func() {
+		It("prints usage by help", func() {
+			Expect(run("help")).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("Usage: dpfctl"))
+		})
+		It("returns ErrUsage without command", func() {
+			Expect(run()).To(MatchError(dpfctl.ErrUsage))
+			Expect(stderr.String()).To(ContainSubstring("Usage: dpfctl"))
+		})
+		It("returns ErrUsage by unknown command", func() {
+			Expect(run("hoge")).To(MatchError(dpfctl.ErrUsage))
+			Expect(stderr.String()).To(ContainSubstring("unknown command `hoge`"))
+		})
+		It("returns ErrUsage by unknown flag", func() {
+			Expect(run("get", "-hoge")).To(MatchError(dpfctl.ErrUsage))
+		})
+		It("returns error without token", func() {
+			delete(env, dpfctl.EnvToken)
+			Expect(run("get", "Zone", "m1")).To(MatchError(ContainSubstring("token is required")))
+		})
+		It("returns error by unknown output format", func() {
+			Expect(run("get", "Zone", "m1", "-o", "xml")).To(MatchError("unknown output format `xml`"))
+		})
+	})
+	Context("get", func() {
+		It("prints table", func() {
+			Expect(run("get", "record", "m1", "r1")).To(Succeed())
+			Expect(stdout.String()).To(MatchRegexp(`ZONE_ID\s+ID\s+NAME\s+TTL\s+RRTYPE\s+RDATA`))
+			Expect(stdout.String()).To(MatchRegexp(`m1\s+r1\s+www.example.jp.\s+300\s+A\s+192.168.0.1`))
+		})
+		It("prints json which can be parsed", func() {
+			Expect(run("get", "Record", "m1", "r1", "-o", "json")).To(Succeed())
+			s, err := schema.SchemaSet.Parse(stdout.Bytes())
+			Expect(err).To(Succeed())
+			Expect(s.(*zones.Record).Name).To(Equal("www.example.jp."))
+		})
+		It("prints yaml", func() {
+			Expect(run("get", "Record", "m1", "r1", "-o", "yaml")).To(Succeed())
+			Expect(stdout.String()).To(HavePrefix("kind: Record\napiVersion: zones.api.dns-platform.jp/v1\nresource:\n"))
+		})
+		It("sets int64 path params", func() {
+			Expect(run("get", "CommonConfig", "f1", "1")).To(Succeed())
+			Expect(stdout.String()).To(MatchRegexp(`f1\s+1\s+default`))
+		})
+		It("returns error by invalid path params", func() {
+			Expect(run("get", "Record", "m1")).To(MatchError(ContainSubstring("failed to set path params")))
+		})
+		It("returns error by ambiguous kind", func() {
+			Expect(run("get", "Contract", "f1")).To(MatchError(ContainSubstring("is ambiguous")))
+			Expect(run("get", "Contract", "f1", "-api-version", "core.api.dns-platform.jp/v1")).To(Succeed())
+		})
+		It("returns api error", func() {
+			Expect(run("get", "Record", "m1", "r9")).To(HaveOccurred())
+		})
+	})
+	Context("list", func() {
+		It("lists by kind of items", func() {
+			Expect(run("list", "Record", "m1")).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("www.example.jp."))
+			Expect(stdout.String()).To(ContainSubstring("mail.example.jp."))
+		})
+		It("lists by query", func() {
+			Expect(run("list", "RecordList", "m1", "-query", "_keywords_name[]=www")).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("www.example.jp."))
+			Expect(stdout.String()).NotTo(ContainSubstring("mail.example.jp."))
+		})
+		It("returns error by not list kind", func() {
+			Expect(run("list", "Job")).To(MatchError("kind `Job` is not list"))
+		})
+	})
+	Context("create", func() {
+		var name string
+		BeforeEach(func() {
+			name = writeManifest(&zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "new.example.jp.",
+				TTL:           300,
+				RRType:        zones.TypeA,
+				RData:         zones.RecordRDATASlice{{Value: "192.168.0.3"}},
+			})
+		})
+		It("prints request id", func() {
+			Expect(run("create", "-f", name)).To(Succeed())
+			requestID := string(bytes.TrimSpace(stdout.Bytes()))
+			Expect(requestID).NotTo(BeEmpty())
+
+			Expect(run("wait-job", requestID, "-o", "json")).To(Succeed())
+			s, err := schema.SchemaSet.Parse(stdout.Bytes())
+			Expect(err).To(Succeed())
+			Expect(s.(*core.Job).Status).To(Equal(core.JobStatusSuccessful))
+		})
+		It("prints created resource with -wait", func() {
+			Expect(run("create", "-f", name, "-wait", "-o", "json")).To(Succeed())
+			s, err := schema.SchemaSet.Parse(stdout.Bytes())
+			Expect(err).To(Succeed())
+			Expect(s.(*zones.Record).ID).NotTo(BeEmpty())
+			Expect(s.(*zones.Record).State).To(Equal(zones.RecordStateToBeAdded))
+		})
+		It("reads yaml file", func() {
+			bs, err := ioutil.ReadFile(name)
+			Expect(err).To(Succeed())
+			bs, err = api.JSONToYAML(bs)
+			Expect(err).To(Succeed())
+			yamlName := filepath.Join(dir, "manifest.yaml")
+			Expect(ioutil.WriteFile(yamlName, bs, 0o600)).To(Succeed())
+			Expect(run("create", "-f", yamlName, "-wait")).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("new.example.jp."))
+		})
+		It("returns error by invalid file", func() {
+			Expect(run("create", "-f", filepath.Join(dir, "not-found.json"))).To(MatchError(ContainSubstring("failed to read")))
+		})
+	})
+	Context("update", func() {
+		It("updates resource by file and path params", func() {
+			name := writeManifest(&zones.Record{TTL: 600, RData: zones.RecordRDATASlice{{Value: "192.168.0.10"}}})
+			Expect(run("update", "-f", name, "-wait", "m1", "r1")).To(Succeed())
+			Expect(run("get", "Record", "m1", "r1")).To(Succeed())
+			Expect(stdout.String()).To(MatchRegexp(`600\s+A\s+192.168.0.10`))
+		})
+	})
+	Context("delete and cancel", func() {
+		It("prints job with -wait", func() {
+			Expect(run("delete", "Record", "m1", "r1", "-wait")).To(Succeed())
+			Expect(stdout.String()).To(ContainSubstring("SUCCESSFUL"))
+			Expect(run("cancel", "Record", "m1", "r1", "-wait")).To(Succeed())
+			Expect(run("get", "Record", "m1", "r1", "-o", "json")).To(Succeed())
+			s, err := schema.SchemaSet.Parse(stdout.Bytes())
+			Expect(err).To(Succeed())
+			Expect(s.(*zones.Record).State).To(Equal(zones.RecordStateApplied))
+		})
+	})
+	Context("wait-job", func() {
+		It("returns error without request id", func() {
+			Expect(run("wait-job")).To(MatchError("wait-job needs a request id"))
+		})
+	})
+	Context("schema", func() {
+		var res map[string]interface{}
+		parse := func() map[string]interface{} {
+			res = map[string]interface{}{}
+			Expect(json.Unmarshal(stdout.Bytes(), &res)).To(Succeed())
+			return res
+		}
+		BeforeEach(func() {
+			delete(env, dpfctl.EnvToken)
+		})
+		It("prints JSON Schema of all manifests without token", func() {
+			Expect(run("schema")).To(Succeed())
+			Expect(parse()).To(HaveKeyWithValue("oneOf", Not(BeEmpty())))
+		})
+		It("prints JSON Schema of manifest of kind", func() {
+			Expect(run("schema", "record")).To(Succeed())
+			Expect(parse()).To(HaveKeyWithValue("title", "Record (zones.api.dns-platform.jp/v1)"))
+			Expect(res["properties"]).To(HaveKey("refs"))
+		})
+		It("prints JSON Schema of request body", func() {
+			Expect(run("schema", "Record", "-action", "update")).To(Succeed())
+			Expect(parse()["properties"]).To(HaveLen(3))
+		})
+		It("returns error by action without request body", func() {
+			Expect(run("schema", "Record", "-action", "delete")).To(MatchError("unknown action `delete`, action must be create, update or apply"))
+			Expect(run("schema", "Record", "-action", "apply")).To(MatchError("kind value `Record` doesn't have request body of action `Apply`"))
+		})
+		It("returns error without kind", func() {
+			Expect(run("schema", "-action", "create")).To(MatchError("schema needs a kind"))
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package dpfctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+func (c *Command) print(o *options, s api.Spec) error {
+	if o.output == outputTable {
+		return writeTable(c.Stdout, s)
+	}
+	if o.output == outputYAML {
+		bs, err := api.MarshalOutputYAML(s)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", schema.KindName(s), err)
+		}
+		_, err = c.Stdout.Write(bs)
+		return err
+	}
+	bs, err := api.MarshalOutput(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", schema.KindName(s), err)
+	}
+	buf := &bytes.Buffer{}
+	if err := json.Indent(buf, bs, "", "  "); err != nil {
+		return fmt.Errorf("failed to format json: %w", err)
+	}
+	buf.WriteString("\n")
+	_, err = buf.WriteTo(c.Stdout)
+	return err
+}
+
+type column struct {
+	name  string
+	index []int
+}
+
+// writeTable writes scalar fields of s, or of items if s is ListSpec.
+func writeTable(w io.Writer, s api.Spec) error {
+	var (
+		rows []reflect.Value
+		t    reflect.Type
+	)
+	if ls, ok := s.(api.ListSpec); ok {
+		t = reflect.TypeOf(ls.GetItems())
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		for i := 0; i < ls.Len(); i++ {
+			rows = append(rows, reflect.Indirect(reflect.ValueOf(ls.Index(i))))
+		}
+	} else {
+		v := reflect.Indirect(reflect.ValueOf(s))
+		t = v.Type()
+		rows = append(rows, v)
+	}
+	columns := tableColumns(t, nil)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	names := make([]string, 0, len(columns))
+	for _, col := range columns {
+		names = append(names, col.name)
+	}
+	fmt.Fprintln(tw, strings.Join(names, "\t"))
+	for _, row := range rows {
+		values := make([]string, 0, len(columns))
+		for _, col := range columns {
+			values = append(values, formatValue(row.FieldByIndex(col.index)))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+func tableColumns(t reflect.Type, index []int) []column {
+	var columns []column
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		idx := append(append([]int{}, index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			columns = append(columns, tableColumns(f.Type, idx)...)
+			continue
+		}
+		if f.PkgPath != "" || !isScalar(f.Type) {
+			continue
+		}
+		columns = append(columns, column{name: columnName(f.Name), index: idx})
+	}
+	return columns
+}
+
+func isScalar(t reflect.Type) bool {
+	if t.Implements(stringerType) || reflect.PtrTo(t).Implements(stringerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// columnName returns upper snake case name, e.g. ZoneID => ZONE_ID.
+func columnName(name string) string {
+	var b strings.Builder
+	rs := []rune(name)
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rs[i-1]) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func formatValue(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,192 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+)
+
+// FailurePolicy decides what Engine does after a resource is failed.
+type FailurePolicy string
+
+const (
+	// remaining resources are skipped.
+	FailurePolicyStop FailurePolicy = "stop"
+	// remaining resources are applied.
+	FailurePolicyContinue FailurePolicy = "continue"
+)
+
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationApply  Operation = "apply"
+)
+
+// Engine applies resources in dependency order.
+// Create or update is decided by existing state,
+// specs which only support apply (e.g. lb_domains.Config, zones.ZoneApply) are applied.
+//
+//	e := manifest.NewEngine(cl)
+//	e.FailurePolicy = manifest.FailurePolicyContinue
+//	report, err := e.Run(ctx, specs)
+type Engine struct {
+	Client api.ClientInterface
+	// Waiter waits each job, DefaultJobWaiter is used if it is nil.
+	Waiter        *apiutils.JobWaiter
+	FailurePolicy FailurePolicy
+	// Find searches existing resource of spec which doesn't have id, FindExisting is used if it is nil.
+	Find Finder
+	// Refs are references of specs given to Run, they are resolved before each resource is applied.
+	Refs Refs
+}
+
+func NewEngine(cl api.ClientInterface) *Engine {
+	return &Engine{
+		Client:        cl,
+		Waiter:        apiutils.DefaultJobWaiter(),
+		FailurePolicy: FailurePolicyStop,
+		Find:          FindExisting,
+	}
+}
+
+// Plan decides operations of specs without changing resources.
+// Existing state is read to decide create or update.
+func (e *Engine) Plan(ctx context.Context, specs []apis.Spec) (*Report, error) {
+	return e.run(ctx, specs, true)
+}
+
+// Run applies specs in order of Rank, then waits the jobs one by one.
+// specs are not modified, Result.Spec has the id of created resource.
+// It returns the report and aggregated errors of failed resources.
+func (e *Engine) Run(ctx context.Context, specs []apis.Spec) (*Report, error) {
+	return e.run(ctx, specs, false)
+}
+
+func (e *Engine) run(ctx context.Context, specs []apis.Spec, dryRun bool) (*Report, error) {
+	copies := make([]apis.Spec, 0, len(specs))
+	refs := Refs{}
+	for _, s := range specs {
+		c, ok := api.DeepCopySpec(s).(apis.Spec)
+		if !ok {
+			return nil, fmt.Errorf("failed to copy %s", s.GetName())
+		}
+		copies = append(copies, c)
+		for _, ref := range e.Refs[s] {
+			if err := validateRef(c, ref); err != nil {
+				return nil, err
+			}
+			refs[c] = append(refs[c], ref)
+		}
+	}
+	Sort(copies)
+
+	report := &Report{}
+	stopped := false
+	for _, s := range copies {
+		res := &Result{Spec: s}
+		report.Results = append(report.Results, res)
+		if stopped {
+			res.Status = StatusSkipped
+			continue
+		}
+		res.Err = e.resolve(s, refs[s], report.Results, dryRun)
+		if res.Err == nil {
+			res.Operation, res.Err = e.decide(ctx, s)
+		}
+		if res.Err == nil {
+			if dryRun {
+				res.Status = StatusPlanned
+				continue
+			}
+			res.RequestID, res.Job, res.Err = e.execute(ctx, s, res.Operation)
+		}
+		if res.Err != nil {
+			res.Status = StatusFailed
+			stopped = e.FailurePolicy != FailurePolicyContinue
+			continue
+		}
+		res.Status = StatusSucceeded
+	}
+	return report, report.Err()
+}
+
+// resolve sets ids of referenced resources to s.
+func (e *Engine) resolve(s apis.Spec, refs []Ref, results []*Result, dryRun bool) error {
+	for _, ref := range refs {
+		if err := resolveRef(s, ref, results, dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decide returns operation of s.
+// If s supports both create and update, existing resource is searched.
+func (e *Engine) decide(ctx context.Context, s apis.Spec) (Operation, error) {
+	create, update, apply := supports(s, api.ActionCreate), supports(s, api.ActionUpdate), supports(s, api.ActionApply)
+	switch {
+	case create && update:
+		exists, err := e.exists(ctx, s)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return OperationUpdate, nil
+		}
+		return OperationCreate, nil
+	case create:
+		return OperationCreate, nil
+	case update:
+		return OperationUpdate, nil
+	case apply:
+		return OperationApply, nil
+	}
+	return "", fmt.Errorf("%s doesn't support create, update and apply", s.GetName())
+}
+
+func (e *Engine) exists(ctx context.Context, s apis.Spec) (bool, error) {
+	if !identified(s) {
+		find := e.Find
+		if find == nil {
+			find = FindExisting
+		}
+		return find(ctx, e.Client, s)
+	}
+	if _, err := e.Client.Read(ctx, api.DeepCopySpec(s)); err != nil {
+		if api.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %w", s.GetName(), err)
+	}
+	return true, nil
+}
+
+func (e *Engine) execute(ctx context.Context, s apis.Spec, op Operation) (string, *core.Job, error) {
+	w := e.Waiter
+	if w == nil {
+		w = apiutils.DefaultJobWaiter()
+	}
+	switch op {
+	case OperationCreate:
+		requestID, job, err := w.SyncCreate(ctx, e.Client, s, nil)
+		if err == nil {
+			// some resources don't have resource url, the id is best effort.
+			_ = apiutils.SetResourceID(s, job)
+		}
+		return requestID, job, err
+	case OperationUpdate:
+		return w.SyncUpdate(ctx, e.Client, s, nil)
+	}
+	return w.SyncApply(ctx, e.Client, s, nil)
+}
+
+func supports(s apis.Spec, action api.Action) bool {
+	_, path := s.GetPathMethod(action)
+	return path != ""
+}
@@ -0,0 +1,182 @@
+package manifest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/manifest"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+var _ = Describe("Engine", func() {
+	var (
+		fs     *testtool.FakeServer
+		srv    *httptest.Server
+		cl     *api.Client
+		ctx    context.Context
+		e      *manifest.Engine
+		specs  []apis.Spec
+		report *manifest.Report
+		err    error
+	)
+	operations := func(r *manifest.Report) []string {
+		var ops []string
+		for _, res := range r.Results {
+			ops = append(ops, string(res.Operation)+" "+res.Path()+" "+string(res.Status))
+		}
+		return ops
+	}
+	BeforeEach(func() {
+		fs = testtool.NewFakeServer()
+		fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."},
+			zones.Record{ID: "r1", Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+		)
+		fs.AddContract(core.Contract{ID: "f1"}, contracts.CommonConfig{ID: 1, Name: "default"})
+		fs.AddLBDomain(core.LBDomain{ID: "b1", Name: "lb.example.jp."})
+		srv = httptest.NewServer(fs)
+		cl = api.NewClient("token", srv.URL, nil)
+		cl.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+		ctx = context.Background()
+		e = manifest.NewEngine(cl)
+		e.Waiter = &apiutils.JobWaiter{InitialInterval: 10 * time.Millisecond, Multiplier: 1.0}
+		specs = []apis.Spec{
+			&zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, Description: "manifest"},
+			&zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, Name: "new.example.jp.", TTL: 300, RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `"hoge"`}}},
+			&zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, Name: "www.example.jp.", TTL: 600, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+			&contracts.CommonConfig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, Name: "default", Description: "updated"},
+			&contracts.CommonConfig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, Name: "new"},
+			&lb_domains.Config{AttributeMeta: lb_domains.AttributeMeta{LBDomainID: "b1"}},
+		}
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	Context("Run", func() {
+		When("all resources are successful", func() {
+			BeforeEach(func() {
+				report, err = e.Run(ctx, specs)
+			})
+			It("applies resources in dependency order", func() {
+				Expect(err).To(Succeed())
+				Expect(operations(report)).To(Equal([]string{
+					"update /contracts/f1/common_configs/1 succeeded",
+					"create /contracts/f1/common_configs/" + report.Results[1].Path()[len("/contracts/f1/common_configs/"):] + " succeeded",
+					"create /zones/m1/records/" + report.Results[2].Spec.(*zones.Record).ID + " succeeded",
+					"update /zones/m1/records/r1 succeeded",
+					"apply /lb_domains/b1/config succeeded",
+					"apply /zones/m1/changes succeeded",
+				}))
+				Expect(report.Succeeded()).To(HaveLen(6))
+				Expect(report.Results[0].RequestID).NotTo(BeEmpty())
+				Expect(report.Results[0].Job).NotTo(BeNil())
+			})
+			It("sets id of created resources", func() {
+				Expect(report.Results[1].Spec.(*contracts.CommonConfig).ID).NotTo(BeZero())
+				Expect(report.Results[2].Spec.(*zones.Record).ID).NotTo(BeEmpty())
+			})
+			It("doesn't modify specs", func() {
+				Expect(specs[1].(*zones.Record).ID).To(BeEmpty())
+			})
+			It("changes server state", func() {
+				r := &zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, ID: "r1"}
+				_, err = cl.Read(ctx, r)
+				Expect(err).To(Succeed())
+				Expect(r.TTL).To(Equal(types.NullablePositiveInt32(600)))
+				cc := &contracts.CommonConfig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, ID: 1}
+				_, err = cl.Read(ctx, cc)
+				Expect(err).To(Succeed())
+				Expect(cc.Description).To(Equal("updated"))
+			})
+			It("writes report", func() {
+				buf := &bytes.Buffer{}
+				Expect(report.WriteText(buf)).To(Succeed())
+				Expect(buf.String()).To(HavePrefix("KIND "))
+				Expect(buf.String()).To(ContainSubstring("ZoneApply "))
+				Expect(buf.String()).To(ContainSubstring("6 succeeded, 0 failed, 0 skipped"))
+			})
+		})
+		When("resource has id", func() {
+			BeforeEach(func() {
+				specs = []apis.Spec{
+					&zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, ID: "r1", Name: "www.example.jp.", TTL: 600, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+					&zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, ID: "r9", Name: "new.example.jp.", TTL: 600, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+				}
+				report, err = e.Plan(ctx, specs)
+			})
+			It("decides operation by reading the resource", func() {
+				Expect(err).To(Succeed())
+				Expect(report.Results[0].Operation).To(Equal(manifest.OperationUpdate))
+				Expect(report.Results[1].Operation).To(Equal(manifest.OperationCreate))
+			})
+		})
+		When("resource is failed", func() {
+			BeforeEach(func() {
+				fs.InjectError(http.MethodPost, "/zones/m1/records", &api.BadResponse{
+					StatusCode:   http.StatusBadRequest,
+					ErrorType:    api.ErrorTypeParamaterError,
+					ErrorMessage: "Invalid parameter.",
+				})
+			})
+			It("skips remaining resources by FailurePolicyStop", func() {
+				report, err = e.Run(ctx, specs)
+				Expect(err).To(HaveOccurred())
+				errs := manifest.ResultErrors{}
+				Expect(errors.As(err, &errs)).To(BeTrue())
+				Expect(errs).To(HaveLen(1))
+				Expect(api.IsParameterError(errs[0])).To(BeTrue())
+				Expect(operations(report)[2:]).To(Equal([]string{
+					"create /zones/m1/records/ failed",
+					" /zones/m1/records/ skipped",
+					" /lb_domains/b1/config skipped",
+					" /zones/m1/changes skipped",
+				}))
+			})
+			It("applies remaining resources by FailurePolicyContinue", func() {
+				e.FailurePolicy = manifest.FailurePolicyContinue
+				report, err = e.Run(ctx, specs)
+				Expect(err).To(HaveOccurred())
+				Expect(report.Failed()).To(HaveLen(1))
+				Expect(report.Succeeded()).To(HaveLen(5))
+				Expect(report.Skipped()).To(BeEmpty())
+			})
+		})
+	})
+	Context("Plan", func() {
+		BeforeEach(func() {
+			report, err = e.Plan(ctx, specs)
+		})
+		It("decides operations", func() {
+			Expect(err).To(Succeed())
+			Expect(operations(report)).To(Equal([]string{
+				"update /contracts/f1/common_configs/1 planned",
+				"create /contracts/f1/common_configs/0 planned",
+				"create /zones/m1/records/ planned",
+				"update /zones/m1/records/r1 planned",
+				"apply /lb_domains/b1/config planned",
+				"apply /zones/m1/changes planned",
+			}))
+		})
+		It("doesn't change resources", func() {
+			list := &zones.CurrentRecordList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			_, err = cl.ListAll(ctx, list, nil)
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+		})
+	})
+})
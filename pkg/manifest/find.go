@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+)
+
+// Finder searches existing resource of s which doesn't have id.
+// If it is found, Finder sets the id to s and returns true.
+type Finder func(ctx context.Context, cl api.ClientInterface, s apis.Spec) (bool, error)
+
+var _ Finder = FindExisting
+
+// FindExisting searches existing resource by natural key.
+//
+//	zones.Record               Name, RRType
+//	contracts.Tsig             Name
+//	contracts.CommonConfig     Name
+//	common_configs.CcPrimary   Address
+//
+// Other specs are never found.
+func FindExisting(ctx context.Context, cl api.ClientInterface, s apis.Spec) (bool, error) {
+	switch v := s.(type) {
+	case *zones.Record:
+		r, err := apiutils.GetRecordFromZoneID(ctx, cl, v.ZoneID, v.Name, v.RRType)
+		if errors.Is(err, apiutils.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		v.ID = r.ID
+		return true, nil
+	case *contracts.Tsig:
+		list := &contracts.TsigList{AttributeMeta: v.AttributeMeta}
+		keywords := &contracts.TsigListSearchKeywords{Name: api.KeywordsString{v.Name}}
+		if _, err := cl.ListAll(ctx, list, keywords); err != nil {
+			return false, fmt.Errorf("failed to search tsigs: %w", err)
+		}
+		for _, item := range list.Items {
+			if item.Name == v.Name {
+				v.ID = item.ID
+				return true, nil
+			}
+		}
+	case *contracts.CommonConfig:
+		list := &contracts.CommonConfigList{AttributeMeta: v.AttributeMeta}
+		keywords := &contracts.CommonConfigListSearchKeywords{Name: api.KeywordsString{v.Name}}
+		if _, err := cl.ListAll(ctx, list, keywords); err != nil {
+			return false, fmt.Errorf("failed to search common configs: %w", err)
+		}
+		for _, item := range list.Items {
+			if item.Name == v.Name {
+				v.ID = item.ID
+				return true, nil
+			}
+		}
+	case *common_configs.CcPrimary:
+		list := &common_configs.CcPrimaryList{AttributeMeta: v.AttributeMeta}
+		if _, err := cl.List(ctx, list, nil); err != nil {
+			return false, fmt.Errorf("failed to search cc primaries: %w", err)
+		}
+		for _, item := range list.Items {
+			if item.Address.Equal(v.Address) {
+				v.ID = item.ID
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// identified returns true, if s has id of the resource.
+func identified(s apis.Spec) bool {
+	switch v := s.(type) {
+	case *zones.Record:
+		return v.ID != ""
+	case interface{ GetID() int64 }:
+		return v.GetID() != 0
+	case interface{ GetResourceName() string }:
+		return v.GetResourceName() != ""
+	}
+	return true
+}
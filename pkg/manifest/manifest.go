@@ -0,0 +1,122 @@
+// Package manifest applies many resources written in file format.
+//
+//	specs, refs, err := manifest.Load("manifests/")
+//	e := manifest.NewEngine(cl)
+//	e.Refs = refs
+//	report, err := e.Run(ctx, specs)
+//	report.WriteText(os.Stdout)
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+)
+
+// Extensions are file extensions which are loaded from directory.
+var Extensions = []string{".json", ".yaml", ".yml"}
+
+// Load parses manifest files, a file may have `---` separated documents.
+// If path is directory, files which have Extensions are loaded in name order.
+// Sub directories are not loaded.
+// `refs` of documents are returned as Refs, see Ref.
+func Load(paths ...string) ([]apis.Spec, Refs, error) {
+	var specs []apis.Spec
+	refs := Refs{}
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range files {
+			bs, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			docs, err := api.YAMLStreamToJSON(bs)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			for i, doc := range docs {
+				s, docRefs, err := parseDocument(doc)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to parse %s: document %d: %w", file, i+1, err)
+				}
+				specs = append(specs, s)
+				if len(docRefs) > 0 {
+					refs[s] = docRefs
+				}
+			}
+		}
+	}
+	return specs, refs, nil
+}
+
+func parseDocument(doc []byte) (apis.Spec, []Ref, error) {
+	s, err := schema.SchemaSet.Parse(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	frame := struct {
+		Refs map[string]Ref `json:"refs"`
+	}{}
+	if err := json.Unmarshal(doc, &frame); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse refs: %w", err)
+	}
+	fields := make([]string, 0, len(frame.Refs))
+	for field := range frame.Refs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	refs := make([]Ref, 0, len(fields))
+	for _, field := range fields {
+		ref := frame.Refs[field]
+		ref.Field = field
+		if err := validateRef(s, ref); err != nil {
+			return nil, nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return s, refs, nil
+}
+
+func manifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !hasExtension(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func hasExtension(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package manifest_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/manifest"
+)
+
+var _ = Describe("manifest", func() {
+	Context("Sort", func() {
+		It("sorts specs by dependency", func() {
+			specs := []apis.Spec{
+				&zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}},
+				&lb_domains.RuleMethod{},
+				&zones.Record{Name: "www.example.jp."},
+				&common_configs.CcPrimary{},
+				&lb_domains.Site{},
+				&contracts.Tsig{Name: "tsig1"},
+				&zones.Record{Name: "mail.example.jp."},
+			}
+			manifest.Sort(specs)
+			Expect(specs).To(Equal([]apis.Spec{
+				&contracts.Tsig{Name: "tsig1"},
+				&common_configs.CcPrimary{},
+				&zones.Record{Name: "www.example.jp."},
+				&zones.Record{Name: "mail.example.jp."},
+				&lb_domains.Site{},
+				&lb_domains.RuleMethod{},
+				&zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}},
+			}))
+		})
+	})
+	Context("Load", func() {
+		var (
+			dir   string
+			specs []apis.Spec
+			refs  manifest.Refs
+			err   error
+		)
+		write := func(name, body string) string {
+			path := filepath.Join(dir, name)
+			Expect(ioutil.WriteFile(path, []byte(body), 0o600)).To(Succeed())
+			return path
+		}
+		BeforeEach(func() {
+			dir, err = ioutil.TempDir("", "manifest")
+			Expect(err).To(Succeed())
+			write("a.yaml", "apiVersion: zones.api.dns-platform.jp/v1\nkind: ZoneApply\nresource:\n  ZoneID: m1\n---\napiVersion: zones.api.dns-platform.jp/v1\nkind: Record\nresource:\n  ZoneID: m1\n  Name: www.example.jp.\n  RRType: A\n")
+			write("b.json", `{"apiVersion":"contracts.api.dns-platform.jp/v1","kind":"Tsig","resource":{"ContractID":"f1","Name":"tsig1"}}`)
+			write("c.txt", "not manifest")
+			write("d.yml", "apiVersion: common-configs.api.dns-platform.jp/v1\nkind: CcPrimary\nresource:\n  CommonConfigID: 1\n  Address: 192.168.0.1\nrefs:\n  TsigID:\n    kind: Tsig\n    name: tsig1\n")
+			Expect(os.Mkdir(filepath.Join(dir, "sub"), 0o700)).To(Succeed())
+			write(filepath.Join("sub", "d.yaml"), "broken")
+		})
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+		When("path is directory", func() {
+			BeforeEach(func() {
+				specs, refs, err = manifest.Load(dir)
+			})
+			It("loads manifest files in name order", func() {
+				Expect(err).To(Succeed())
+				Expect(specs).To(Equal([]apis.Spec{
+					&zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}},
+					&zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, Name: "www.example.jp.", RRType: zones.TypeA},
+					&contracts.Tsig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, Name: "tsig1"},
+					&common_configs.CcPrimary{AttributeMeta: common_configs.AttributeMeta{CommonConfigID: 1}, Address: net.ParseIP("192.168.0.1")},
+				}))
+			})
+			It("returns refs", func() {
+				Expect(refs).To(Equal(manifest.Refs{
+					specs[3]: {{Field: "TsigID", Kind: "Tsig", Name: "tsig1"}},
+				}))
+			})
+		})
+		When("path is file", func() {
+			BeforeEach(func() {
+				specs, refs, err = manifest.Load(filepath.Join(dir, "b.json"))
+			})
+			It("loads the file", func() {
+				Expect(err).To(Succeed())
+				Expect(specs).To(HaveLen(1))
+			})
+		})
+		When("refs field is not exist", func() {
+			BeforeEach(func() {
+				specs, refs, err = manifest.Load(write("e.yaml", "apiVersion: contracts.api.dns-platform.jp/v1\nkind: Tsig\nresource:\n  Name: tsig1\nrefs:\n  ZoneID:\n    kind: Zone\n    name: example.jp.\n"))
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("refs ZoneID: Tsig doesn't have the field"))
+			})
+		})
+		When("file is broken", func() {
+			BeforeEach(func() {
+				specs, refs, err = manifest.Load(filepath.Join(dir, "sub"))
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("d.yaml"))
+			})
+		})
+		When("path is not exist", func() {
+			BeforeEach(func() {
+				specs, refs, err = manifest.Load(filepath.Join(dir, "none"))
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,50 @@
+package manifest
+
+import (
+	"sort"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+)
+
+// Rank returns the order of s, resources which have small rank are applied first.
+//
+//	contracts (Tsig, CommonConfig, ...)    10
+//	common_configs (CcPrimary, ...)        20
+//	core, zones (Record, ...)              30
+//	lb_domains Monitoring, Config          30
+//	lb_domains Site, Endpoint, Rule        31-33
+//	lb_domains RuleMethod                  34
+//	zones.ZoneApply, core.DelegationApply  40
+func Rank(s apis.Spec) int {
+	switch s.(type) {
+	case *zones.ZoneApply, *core.DelegationApply:
+		return 40
+	case *lb_domains.Site:
+		return 31
+	case *lb_domains.Endpoint:
+		return 32
+	case *lb_domains.Rule:
+		return 33
+	case *lb_domains.RuleMethod:
+		return 34
+	}
+	switch s.GetGroup() {
+	case (&contracts.AttributeMeta{}).GetGroup():
+		return 10
+	case (&common_configs.AttributeMeta{}).GetGroup():
+		return 20
+	}
+	return 30
+}
+
+// Sort sorts specs by Rank, the order of same rank is kept.
+func Sort(specs []apis.Spec) {
+	sort.SliceStable(specs, func(i, j int) bool {
+		return Rank(specs[i]) < Rank(specs[j])
+	})
+}
@@ -0,0 +1,121 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+)
+
+// Ref sets id of another resource in the same run to Field,
+// e.g. TsigID of common_configs.CcPrimary is set to ID of contracts.Tsig named Name.
+// The resource is matched by Kind and Name field, then its ID field is used.
+//
+//	apiVersion: common-configs.api.dns-platform.jp/v1
+//	kind: CcPrimary
+//	resource:
+//	  CommonConfigID: 1
+//	  Address: 192.168.0.1
+//	refs:
+//	  TsigID:
+//	    kind: Tsig
+//	    name: tsig1
+type Ref struct {
+	Field string `json:"-"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// Refs are references of specs, the key is the spec which has the fields.
+type Refs map[apis.Spec][]Ref
+
+// JSONSchema returns JSON Schema of manifest of kind,
+// it is schema.SchemaSet.ManifestJSONSchema with `refs`.
+func JSONSchema(apiVersion, kind string) (*schema.JSONSchema, error) {
+	res, err := schema.SchemaSet.ManifestJSONSchema(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	addRefsJSONSchema(res)
+	return res, nil
+}
+
+// JSONSchemas returns JSON Schema which accepts manifests of all registered kinds.
+func JSONSchemas() *schema.JSONSchema {
+	res := schema.SchemaSet.ManifestsJSONSchema()
+	for _, frame := range res.OneOf {
+		addRefsJSONSchema(frame)
+	}
+	return res
+}
+
+// addRefsJSONSchema adds `refs` to properties of manifest frame, the key is the field name.
+func addRefsJSONSchema(frame *schema.JSONSchema) {
+	frame.Properties["refs"] = &schema.JSONSchema{
+		Type: "object",
+		AdditionalProperties: &schema.JSONSchema{
+			Type: "object",
+			Properties: map[string]*schema.JSONSchema{
+				"kind": {Type: "string"},
+				"name": {Type: "string"},
+			},
+			Required:             []string{"kind", "name"},
+			AdditionalProperties: false,
+		},
+	}
+}
+
+// validateRef returns error, if s doesn't have the field of ref.
+func validateRef(s apis.Spec, ref Ref) error {
+	if ref.Kind == "" || ref.Name == "" {
+		return fmt.Errorf("refs %s: kind and name are required", ref.Field)
+	}
+	f := reflect.ValueOf(s).Elem().FieldByName(ref.Field)
+	if !f.IsValid() {
+		return fmt.Errorf("refs %s: %s doesn't have the field", ref.Field, schema.KindName(s))
+	}
+	switch f.Kind() {
+	case reflect.Int64, reflect.String:
+		return nil
+	}
+	return fmt.Errorf("refs %s: the field is not id", ref.Field)
+}
+
+// resolveRef sets id of the referenced resource to s.
+// The referenced resource must be applied before s, Rank decides the order.
+// If dryRun is true, the id is not set because created resources don't have id.
+func resolveRef(s apis.Spec, ref Ref, results []*Result, dryRun bool) error {
+	var target *Result
+	for _, res := range results {
+		if res.Spec != s && schema.KindName(res.Spec) == ref.Kind && specName(res.Spec) == ref.Name {
+			target = res
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("refs %s: %s `%s` is not applied before %s", ref.Field, ref.Kind, ref.Name, schema.KindName(s))
+	}
+	if dryRun && target.Status == StatusPlanned {
+		return nil
+	}
+	id := reflect.ValueOf(target.Spec).Elem().FieldByName("ID")
+	if target.Status != StatusSucceeded || !id.IsValid() || id.IsZero() {
+		return fmt.Errorf("refs %s: %s `%s` is not resolved", ref.Field, ref.Kind, ref.Name)
+	}
+	f := reflect.ValueOf(s).Elem().FieldByName(ref.Field)
+	if !id.Type().ConvertibleTo(f.Type()) || id.Kind() != f.Kind() {
+		return fmt.Errorf("refs %s: ID of %s can't be set", ref.Field, ref.Kind)
+	}
+	f.Set(id.Convert(f.Type()))
+	return nil
+}
+
+// specName returns Name field of s.
+func specName(s apis.Spec) string {
+	f := reflect.ValueOf(s).Elem().FieldByName("Name")
+	if !f.IsValid() || f.Kind() != reflect.String {
+		return ""
+	}
+	return f.String()
+}
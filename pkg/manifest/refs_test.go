@@ -0,0 +1,145 @@
+package manifest_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/manifest"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+var _ = Describe("Refs", func() {
+	var (
+		c       *testtool.TestClient
+		e       *manifest.Engine
+		tsig    *contracts.Tsig
+		primary *common_configs.CcPrimary
+		created []api.Spec
+		report  *manifest.Report
+		err     error
+	)
+	resourceURLs := map[string]string{
+		"tsigs":        "https://api.dns-platform.jp/dpf/v1/contracts/f1/tsigs/5",
+		"cc_primaries": "https://api.dns-platform.jp/dpf/v1/common_configs/1/cc_primaries/7",
+	}
+	BeforeEach(func() {
+		created = nil
+		c = testtool.NewTestClient("token", "http://localhost", nil)
+		c.ListAllFunc = func(api.CountableListSpec, api.SearchParams) (string, error) {
+			return "", nil
+		}
+		c.ListFunc = func(api.ListSpec, api.SearchParams) (string, error) {
+			return "", nil
+		}
+		c.CreateFunc = func(s api.Spec, _ interface{}) (string, error) {
+			created = append(created, api.DeepCopySpec(s))
+			return s.GetName(), nil
+		}
+		c.ReadFunc = func(s api.Spec) (string, error) {
+			job := s.(*core.Job)
+			job.Status = core.JobStatusSuccessful
+			job.ResourceUrl = resourceURLs[job.RequestID]
+			return "", nil
+		}
+		e = manifest.NewEngine(c)
+		e.Waiter = &apiutils.JobWaiter{InitialInterval: time.Millisecond, Multiplier: 1}
+		tsig = &contracts.Tsig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, Name: "tsig1"}
+		primary = &common_configs.CcPrimary{AttributeMeta: common_configs.AttributeMeta{CommonConfigID: 1}, Address: net.ParseIP("192.168.0.1")}
+	})
+	When("referenced resource is created in the same run", func() {
+		BeforeEach(func() {
+			e.Refs = manifest.Refs{primary: {{Field: "TsigID", Kind: "Tsig", Name: "tsig1"}}}
+			report, err = e.Run(context.Background(), []apis.Spec{primary, tsig})
+		})
+		It("sets id of the created resource", func() {
+			Expect(err).To(Succeed())
+			Expect(created).To(HaveLen(2))
+			Expect(created[1].(*common_configs.CcPrimary).TsigID).To(Equal(types.NullablePositiveInt64(5)))
+			Expect(report.Results[1].Spec.(*common_configs.CcPrimary).ID).To(Equal(int64(7)))
+		})
+		It("doesn't modify specs", func() {
+			Expect(primary.TsigID).To(BeZero())
+		})
+	})
+	When("referenced resource is not in the run", func() {
+		BeforeEach(func() {
+			e.Refs = manifest.Refs{primary: {{Field: "TsigID", Kind: "Tsig", Name: "tsig2"}}}
+			report, err = e.Run(context.Background(), []apis.Spec{primary, tsig})
+		})
+		It("returns error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(report.Results[1].Status).To(Equal(manifest.StatusFailed))
+			Expect(report.Results[1].Err.Error()).To(Equal("refs TsigID: Tsig `tsig2` is not applied before CcPrimary"))
+			Expect(created).To(HaveLen(1))
+		})
+	})
+	When("field is not exist", func() {
+		BeforeEach(func() {
+			e.Refs = manifest.Refs{primary: {{Field: "TsigName", Kind: "Tsig", Name: "tsig1"}}}
+			report, err = e.Run(context.Background(), []apis.Spec{primary, tsig})
+		})
+		It("returns error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(report).To(BeNil())
+		})
+	})
+	When("plan", func() {
+		BeforeEach(func() {
+			e.Refs = manifest.Refs{primary: {{Field: "TsigID", Kind: "Tsig", Name: "tsig1"}}}
+			report, err = e.Plan(context.Background(), []apis.Spec{primary, tsig})
+		})
+		It("doesn't need the id", func() {
+			Expect(err).To(Succeed())
+			Expect(report.Results[1].Status).To(Equal(manifest.StatusPlanned))
+			Expect(created).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("JSONSchema", func() {
+	Context("JSONSchema", func() {
+		It("adds refs to JSON Schema of file format", func() {
+			res, err := manifest.JSONSchema("common-configs.api.dns-platform.jp/v1", "CcPrimary")
+			Expect(err).To(Succeed())
+			Expect(res.Schema).To(Equal(schema.JSONSchemaDraft))
+			Expect(res.Properties).To(HaveKey("resource"))
+			Expect(res.Properties["refs"].Type).To(Equal("object"))
+			ref := res.Properties["refs"].AdditionalProperties.(*schema.JSONSchema)
+			Expect(ref.Properties).To(HaveLen(2))
+			Expect(ref.Required).To(Equal([]string{"kind", "name"}))
+			Expect(res.Required).NotTo(ContainElement("refs"))
+		})
+		It("doesn't change JSON Schema of file format", func() {
+			_, err := manifest.JSONSchema("common-configs.api.dns-platform.jp/v1", "CcPrimary")
+			Expect(err).To(Succeed())
+			res, err := schema.SchemaSet.ManifestJSONSchema("common-configs.api.dns-platform.jp/v1", "CcPrimary")
+			Expect(err).To(Succeed())
+			Expect(res.Properties).NotTo(HaveKey("refs"))
+		})
+		It("returns error, if kind is not found", func() {
+			_, err := manifest.JSONSchema("test", "CcPrimary")
+			Expect(err).To(MatchError("apiVersion `test` is not support"))
+		})
+	})
+	Context("JSONSchemas", func() {
+		It("adds refs to all kinds", func() {
+			res := manifest.JSONSchemas()
+			Expect(res.OneOf).NotTo(BeEmpty())
+			for _, frame := range res.OneOf {
+				Expect(frame.Properties).To(HaveKey("refs"))
+			}
+		})
+	})
+})
@@ -0,0 +1,117 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+)
+
+type Status string
+
+const (
+	// operation is decided, but it is not executed by Plan.
+	StatusPlanned   Status = "planned"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// not executed, because previous resource was failed.
+	StatusSkipped Status = "skipped"
+)
+
+// Result is result of a resource.
+type Result struct {
+	Spec apis.Spec
+	// Operation is empty, when it is skipped or failed to decide the operation.
+	Operation Operation
+	Status    Status
+	RequestID string
+	Job       *core.Job
+	Err       error
+}
+
+// Path returns read path of the resource, or path of the operation if the spec is not readable.
+func (r *Result) Path() string {
+	if _, path := r.Spec.GetPathMethod(api.ActionRead); path != "" {
+		return path
+	}
+	_, path := r.Spec.GetPathMethod(operationAction(r.Operation))
+	return path
+}
+
+func (r *Result) Error() string {
+	return fmt.Sprintf("%s %s %s: %s", r.Operation, r.Spec.GetName(), r.Path(), r.Err)
+}
+
+func (r *Result) Unwrap() error {
+	return r.Err
+}
+
+func operationAction(op Operation) api.Action {
+	switch op {
+	case OperationCreate:
+		return api.ActionCreate
+	case OperationUpdate:
+		return api.ActionUpdate
+	}
+	return api.ActionApply
+}
+
+// ResultErrors is aggregated errors of Engine.
+type ResultErrors []*Result
+
+func (e ResultErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, res := range e {
+		msgs = append(msgs, res.Error())
+	}
+	return fmt.Sprintf("%d resources failed: %s", len(e), strings.Join(msgs, ", "))
+}
+
+// Report is results of Engine in applied order.
+type Report struct {
+	Results []*Result
+}
+
+func (r *Report) filter(status Status) []*Result {
+	var results []*Result
+	for _, res := range r.Results {
+		if res.Status == status {
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+func (r *Report) Succeeded() []*Result { return r.filter(StatusSucceeded) }
+func (r *Report) Failed() []*Result    { return r.filter(StatusFailed) }
+func (r *Report) Skipped() []*Result   { return r.filter(StatusSkipped) }
+
+// Err returns ResultErrors of failed resources.
+// It returns nil, if no resource is failed.
+func (r *Report) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return ResultErrors(failed)
+}
+
+// WriteText writes the results as table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tPATH\tOPERATION\tSTATUS\tREQUEST_ID\tERROR")
+	for _, res := range r.Results {
+		msg := ""
+		if res.Err != nil {
+			msg = res.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", schema.KindName(res.Spec), res.Path(), res.Operation, res.Status, res.RequestID, msg)
+	}
+	fmt.Fprintf(tw, "\n%d succeeded, %d failed, %d skipped\n", len(r.Succeeded()), len(r.Failed()), len(r.Skipped()))
+	return tw.Flush()
+}
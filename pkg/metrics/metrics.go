@@ -0,0 +1,130 @@
+// Package metrics provides instrumentation of api.Client in Prometheus text format.
+//
+//	m := metrics.New()
+//	cl := api.New(token, m.ClientOptions()...)
+//	ctx = m.Context(ctx) // observe apiutils.WaitJob
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+)
+
+const Namespace = "dpf"
+
+var (
+	// DefaultBuckets are buckets of request and rate limit durations in seconds.
+	DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// JobBuckets are buckets of job wait durations in seconds.
+	JobBuckets = []float64{1, 2, 5, 10, 30, 60, 120, 300, 600}
+)
+
+var _ http.Handler = &Metrics{}
+
+// Metrics has metrics of api calls, rate limiting and jobs.
+type Metrics struct {
+	Registry *Registry
+
+	// dpf_api_requests_total{action,group,name,status,error_type}
+	// status is empty, when the client failed to get response.
+	Requests *CounterVec
+	// dpf_api_request_duration_seconds{action,group,name}
+	RequestDuration *HistogramVec
+	// dpf_api_rate_limit_wait_seconds
+	RateLimitWait *HistogramVec
+	// dpf_job_wait_duration_seconds{status}
+	// status is empty, when WaitJob failed to read the job.
+	JobWaitDuration *HistogramVec
+}
+
+// New returns Metrics registered to new Registry.
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		Registry: r,
+		Requests: r.NewCounterVec(Namespace+"_api_requests_total",
+			"Total number of DPF API requests.",
+			"action", "group", "name", "status", "error_type"),
+		RequestDuration: r.NewHistogramVec(Namespace+"_api_request_duration_seconds",
+			"Duration of DPF API requests including retries.",
+			DefaultBuckets, "action", "group", "name"),
+		RateLimitWait: r.NewHistogramVec(Namespace+"_api_rate_limit_wait_seconds",
+			"Time spent waiting for the client side rate limit.",
+			DefaultBuckets),
+		JobWaitDuration: r.NewHistogramVec(Namespace+"_job_wait_duration_seconds",
+			"Duration of waiting for DPF jobs.",
+			JobBuckets, "status"),
+	}
+}
+
+// ClientOptions returns options to instrument api.Client.
+func (m *Metrics) ClientOptions() []api.ClientOption {
+	return []api.ClientOption{
+		api.WithMiddleware(m.Middleware()),
+		api.WithRateLimitObserver(m.ObserveRateLimitWait),
+	}
+}
+
+// Middleware returns api.Middleware which observes requests.
+func (m *Metrics) Middleware() api.Middleware {
+	return func(next api.Invoker) api.Invoker {
+		return func(ctx context.Context, call *api.Call) (string, error) {
+			status := ""
+			prev := api.ResponseHookFromContext(ctx)
+			ctx = api.ContextWithResponseHook(ctx, func(ctx context.Context, reqInfo *api.RequestInfo, respInfo *api.ResponseInfo) {
+				if respInfo != nil && respInfo.Response != nil {
+					status = strconv.Itoa(respInfo.Response.StatusCode)
+				}
+				if prev != nil {
+					prev(ctx, reqInfo, respInfo)
+				}
+			})
+			start := time.Now()
+			requestID, err := next(ctx, call)
+			group, name := call.Spec.GetGroup(), call.Spec.GetName()
+			errorType := ""
+			bad := &api.BadResponse{}
+			if errors.As(err, &bad) {
+				errorType = bad.ErrorType
+				if status == "" {
+					status = strconv.Itoa(bad.StatusCode)
+				}
+			}
+			m.Requests.Inc(string(call.Action), group, name, status, errorType)
+			m.RequestDuration.Observe(time.Since(start).Seconds(), string(call.Action), group, name)
+			return requestID, err
+		}
+	}
+}
+
+// ObserveRateLimitWait is api.RateLimitObserver.
+func (m *Metrics) ObserveRateLimitWait(_ context.Context, wait time.Duration) {
+	m.RateLimitWait.Observe(wait.Seconds())
+}
+
+// ObserveJob is apiutils.JobObserver.
+func (m *Metrics) ObserveJob(_ context.Context, _ string, job *core.Job, wait time.Duration, _ error) {
+	status := ""
+	if job != nil {
+		status = string(job.Status)
+	}
+	m.JobWaitDuration.Observe(wait.Seconds(), status)
+}
+
+// Context returns ctx which observes apiutils.WaitJob.
+func (m *Metrics) Context(ctx context.Context) context.Context {
+	return apiutils.ContextWithJobObserver(ctx, m.ObserveJob)
+}
+
+// ServeHTTP writes metrics in Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Registry.ServeHTTP(w, r)
+}
@@ -0,0 +1,133 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/metrics"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("Registry", func() {
+	var (
+		r   *metrics.Registry
+		buf *bytes.Buffer
+	)
+	BeforeEach(func() {
+		r = metrics.NewRegistry()
+		buf = bytes.NewBuffer(nil)
+	})
+	It("writes counter", func() {
+		c := r.NewCounterVec("test_total", "Test counter.", "code")
+		c.Inc("b")
+		c.Add(2, "a\"")
+		Expect(r.WriteText(buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{code="a\""} 2
+test_total{code="b"} 1
+`))
+	})
+	It("writes histogram", func() {
+		h := r.NewHistogramVec("test_seconds", "Test histogram.", []float64{1, 0.5})
+		h.Observe(0.2)
+		h.Observe(0.7)
+		h.Observe(3)
+		Expect(r.WriteText(buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.5"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 3.9
+test_seconds_count 3
+`))
+	})
+	It("panics with invalid number of label values", func() {
+		c := r.NewCounterVec("test_total", "Test counter.", "code")
+		Expect(func() { c.Inc() }).To(Panic())
+	})
+})
+
+var _ = Describe("Metrics", func() {
+	var (
+		m   *metrics.Metrics
+		fs  *testtool.FakeServer
+		srv *httptest.Server
+		cl  *api.Client
+		ctx context.Context
+		err error
+	)
+	BeforeEach(func() {
+		m = metrics.New()
+		fs = testtool.NewFakeServer()
+		fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."})
+		srv = httptest.NewServer(fs)
+		cl = api.New("token", append(m.ClientOptions(), api.WithEndpoint(srv.URL))...)
+		ctx = m.Context(context.Background())
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	When("request is successful", func() {
+		BeforeEach(func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+		})
+		It("counts request with status", func() {
+			Expect(err).To(Succeed())
+			Expect(m.Requests.Value("Read", "core.api.dns-platform.jp/v1", "zones", "200", "")).To(Equal(1.0))
+			Expect(m.RequestDuration.Count("Read", "core.api.dns-platform.jp/v1", "zones")).To(Equal(uint64(1)))
+		})
+		It("observes rate limit wait", func() {
+			Expect(m.RateLimitWait.Count()).To(Equal(uint64(1)))
+		})
+	})
+	When("request is failed", func() {
+		BeforeEach(func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m2"})
+		})
+		It("counts request with error type", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(m.Requests.Value("Read", "core.api.dns-platform.jp/v1", "zones", "404", "NotFound")).To(Equal(1.0))
+		})
+	})
+	When("job is waited", func() {
+		BeforeEach(func() {
+			_, _, err = apiutils.SyncCreate(ctx, cl, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "www.example.jp.",
+				TTL:           300,
+				RRType:        zones.TypeA,
+				RData:         zones.RecordRDATASlice{{Value: "192.168.0.1"}},
+			}, nil)
+		})
+		It("observes job wait duration", func() {
+			Expect(err).To(Succeed())
+			Expect(m.JobWaitDuration.Count("SUCCESSFUL")).To(Equal(uint64(1)))
+			Expect(m.Requests.Value("Create", "zones.api.dns-platform.jp/v1", "records", "202", "")).To(Equal(1.0))
+		})
+	})
+	Context("ServeHTTP", func() {
+		It("writes metrics", func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(err).To(Succeed())
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			bs, err := ioutil.ReadAll(rec.Body)
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(ContainSubstring(`dpf_api_requests_total{action="Read",group="core.api.dns-platform.jp/v1",name="zones",status="200",error_type=""} 1`))
+			Expect(string(bs)).To(ContainSubstring(`dpf_api_rate_limit_wait_seconds_count 1`))
+		})
+	})
+})
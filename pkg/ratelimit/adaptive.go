@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+)
+
+const (
+	// DefaultAdaptiveInitialDelay is the delay which is added after the first TooManyRequests.
+	DefaultAdaptiveInitialDelay = time.Second
+	// DefaultAdaptiveMaxDelay is the max delay of AdaptiveLimiter.
+	DefaultAdaptiveMaxDelay = time.Minute
+)
+
+var (
+	_ api.Limiter          = &AdaptiveLimiter{}
+	_ api.ResponseObserver = &AdaptiveLimiter{}
+)
+
+// AdaptiveLimiter adds delay to each request after TooManyRequests is returned.
+// The delay is doubled by each TooManyRequests up to MaxDelay,
+// and it is halved by each successful response until it is removed.
+// Requests also wait until Retry-After of TooManyRequests response.
+type AdaptiveLimiter struct {
+	// Limiter is waited before the delay, it may be nil.
+	// If Limiter implements api.ResponseObserver, responses are passed to it.
+	Limiter      api.Limiter
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	mu    sync.Mutex
+	delay time.Duration
+	until time.Time
+}
+
+func NewAdaptiveLimiter(limiter api.Limiter) *AdaptiveLimiter {
+	return &AdaptiveLimiter{
+		Limiter:      limiter,
+		InitialDelay: DefaultAdaptiveInitialDelay,
+		MaxDelay:     DefaultAdaptiveMaxDelay,
+	}
+}
+
+// Delay returns the current delay of each request.
+func (a *AdaptiveLimiter) Delay() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.delay
+}
+
+func (a *AdaptiveLimiter) Wait(ctx context.Context) error {
+	if a.Limiter != nil {
+		if err := a.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	a.mu.Lock()
+	wait := a.delay
+	if d := time.Until(a.until); d > wait {
+		wait = d
+	}
+	a.mu.Unlock()
+	return sleep(ctx, wait)
+}
+
+func (a *AdaptiveLimiter) ObserveResponse(resp *http.Response) {
+	if o, ok := a.Limiter.(api.ResponseObserver); ok {
+		o.ObserveResponse(resp)
+	}
+	if resp == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	initial := a.InitialDelay
+	if initial <= 0 {
+		initial = DefaultAdaptiveInitialDelay
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		now := time.Now()
+		if a.delay < initial {
+			a.delay = initial
+		} else {
+			a.delay *= 2
+		}
+		if a.MaxDelay > 0 && a.delay > a.MaxDelay {
+			a.delay = a.MaxDelay
+		}
+		if until := now.Add(api.ParseRetryAfter(resp.Header.Get("Retry-After"), now)); until.After(a.until) {
+			a.until = until
+		}
+	case resp.StatusCode < http.StatusInternalServerError:
+		a.delay /= 2
+		if a.delay < initial/2 {
+			a.delay = 0
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/ratelimit"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+)
+
+type testObserver struct {
+	api.Limiter
+	statuses []int
+}
+
+func (o *testObserver) ObserveResponse(resp *http.Response) {
+	o.statuses = append(o.statuses, resp.StatusCode)
+}
+
+type testRoundTripper struct {
+	statuses []int
+}
+
+func (t *testRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := t.statuses[0]
+	t.statuses = t.statuses[1:]
+	return &http.Response{StatusCode: status, Header: http.Header{}, Request: req}, nil
+}
+
+var _ = Describe("AdaptiveLimiter", func() {
+	var (
+		a         *ratelimit.AdaptiveLimiter
+		tooMany   *http.Response
+		ok        *http.Response
+		serverErr *http.Response
+	)
+	BeforeEach(func() {
+		a = ratelimit.NewAdaptiveLimiter(rate.NewLimiter(rate.Inf, 0))
+		a.InitialDelay = 20 * time.Millisecond
+		a.MaxDelay = 100 * time.Millisecond
+		tooMany = &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		ok = &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+		serverErr = &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}}
+	})
+	Context("ObserveResponse", func() {
+		It("slows down by TooManyRequests and recovers by success", func() {
+			Expect(a.Delay()).To(Equal(time.Duration(0)))
+			expected := []time.Duration{20, 40, 80, 100, 100}
+			for _, d := range expected {
+				a.ObserveResponse(tooMany)
+				Expect(a.Delay()).To(Equal(d * time.Millisecond))
+			}
+			a.ObserveResponse(serverErr)
+			Expect(a.Delay()).To(Equal(100 * time.Millisecond))
+			expected = []time.Duration{50000, 25000, 12500, 0}
+			for _, d := range expected {
+				a.ObserveResponse(ok)
+				Expect(a.Delay()).To(Equal(d * time.Microsecond))
+			}
+		})
+		It("passes response to Limiter", func() {
+			o := &testObserver{Limiter: rate.NewLimiter(rate.Inf, 0)}
+			a.Limiter = o
+			a.ObserveResponse(tooMany)
+			a.ObserveResponse(ok)
+			Expect(o.statuses).To(Equal([]int{http.StatusTooManyRequests, http.StatusOK}))
+		})
+	})
+	Context("Wait", func() {
+		It("doesn't wait without TooManyRequests", func() {
+			start := time.Now()
+			Expect(a.Wait(context.Background())).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("<", 10*time.Millisecond))
+		})
+		It("waits delay", func() {
+			a.ObserveResponse(tooMany)
+			a.ObserveResponse(tooMany)
+			start := time.Now()
+			Expect(a.Wait(context.Background())).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 40*time.Millisecond))
+		})
+		It("waits until Retry-After", func() {
+			tooMany.Header.Set("Retry-After", "1")
+			a.ObserveResponse(tooMany)
+			start := time.Now()
+			Expect(a.Wait(context.Background())).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 900*time.Millisecond))
+		})
+		It("returns error when ctx is done", func() {
+			a.ObserveResponse(tooMany)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(a.Wait(ctx)).To(MatchError(context.Canceled))
+		})
+	})
+	Context("with RateRoundTripper", func() {
+		It("observes responses", func() {
+			rt := api.NewCustomRateRoundTripper(&testRoundTripper{statuses: []int{http.StatusTooManyRequests, http.StatusOK}}, a)
+			req, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+			Expect(err).To(Succeed())
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(Succeed())
+			Expect(a.Delay()).To(Equal(20 * time.Millisecond))
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(Succeed())
+			Expect(a.Delay()).To(Equal(10 * time.Millisecond))
+		})
+	})
+})
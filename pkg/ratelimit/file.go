@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"golang.org/x/time/rate"
+)
+
+// ErrLockNotSupported is returned by NewFileLimiter on platforms without file lock.
+var ErrLockNotSupported = errors.New("file lock is not supported on this platform")
+
+var (
+	_ api.Limiter          = &FileLimiter{}
+	_ api.ResponseObserver = &FileLimiter{}
+)
+
+// FileLimiter is token bucket limiter whose state is stored in Path.
+// Processes which use the same Path share the budget,
+// the state is read and written while holding exclusive lock of the file.
+// When TooManyRequests is returned, all processes wait until Retry-After.
+// The file lock is supported on unix only.
+type FileLimiter struct {
+	Path  string
+	Limit rate.Limit
+	Burst int
+}
+
+// fileLimiterState is content of FileLimiter file.
+type fileLimiterState struct {
+	tokens float64
+	last   time.Time
+	until  time.Time
+}
+
+// NewFileLimiter returns FileLimiter, it creates path if not exist.
+// It returns ErrLockNotSupported on platforms without file lock.
+func NewFileLimiter(path string, limit rate.Limit, burst int) (*FileLimiter, error) {
+	if !lockSupported {
+		return nil, ErrLockNotSupported
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open limiter file: %w", err)
+	}
+	defer f.Close()
+	return &FileLimiter{Path: path, Limit: limit, Burst: burst}, nil
+}
+
+func (l *FileLimiter) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+func (l *FileLimiter) Wait(ctx context.Context) error {
+	if l.Limit == rate.Inf {
+		return nil
+	}
+	if l.Limit <= 0 {
+		return fmt.Errorf("limit of FileLimiter must be positive")
+	}
+	for {
+		var wait time.Duration
+		err := l.update(func(s *fileLimiterState, now time.Time) {
+			if d := s.until.Sub(now); d > 0 {
+				wait = d
+				return
+			}
+			if s.tokens >= 1 {
+				s.tokens--
+				return
+			}
+			wait = time.Duration((1 - s.tokens) / float64(l.Limit) * float64(time.Second))
+		})
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// ObserveResponse stops requests of all processes until Retry-After of TooManyRequests.
+func (l *FileLimiter) ObserveResponse(resp *http.Response) {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	_ = l.update(func(s *fileLimiterState, now time.Time) {
+		wait := api.ParseRetryAfter(resp.Header.Get("Retry-After"), now)
+		if l.Limit > 0 && l.Limit != rate.Inf {
+			if interval := time.Duration(float64(time.Second) / float64(l.Limit)); wait < interval {
+				wait = interval
+			}
+		}
+		if until := now.Add(wait); until.After(s.until) {
+			s.until = until
+		}
+		s.tokens = 0
+	})
+}
+
+// update locks the file, refills tokens and writes the state changed by fn.
+func (l *FileLimiter) update(fn func(s *fileLimiterState, now time.Time)) error {
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open limiter file: %w", err)
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("failed to lock limiter file: %w", err)
+	}
+	defer func() { _ = unlockFile(f) }()
+
+	now := time.Now()
+	s, err := readFileLimiterState(f)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		s = &fileLimiterState{tokens: l.burst(), last: now}
+	}
+	if elapsed := now.Sub(s.last); elapsed > 0 && l.Limit != rate.Inf {
+		s.tokens = math.Min(l.burst(), s.tokens+elapsed.Seconds()*float64(l.Limit))
+	}
+	s.last = now
+	fn(s, now)
+	return writeFileLimiterState(f, s)
+}
+
+// readFileLimiterState returns nil if the file is empty or broken.
+func readFileLimiterState(f *os.File) (*fileLimiterState, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read limiter file: %w", err)
+	}
+	bs, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read limiter file: %w", err)
+	}
+	var (
+		tokens      float64
+		last, until int64
+	)
+	if _, err := fmt.Sscanf(string(bs), "%g %d %d", &tokens, &last, &until); err != nil {
+		return nil, nil
+	}
+	return &fileLimiterState{tokens: tokens, last: time.Unix(0, last), until: time.Unix(0, until)}, nil
+}
+
+func writeFileLimiterState(f *os.File, s *fileLimiterState) error {
+	var until int64
+	if !s.until.IsZero() {
+		until = s.until.UnixNano()
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write limiter file: %w", err)
+	}
+	if _, err := f.WriteAt([]byte(fmt.Sprintf("%g %d %d\n", s.tokens, s.last.UnixNano(), until)), 0); err != nil {
+		return fmt.Errorf("failed to write limiter file: %w", err)
+	}
+	return nil
+}
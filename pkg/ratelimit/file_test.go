@@ -0,0 +1,105 @@
+package ratelimit_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/ratelimit"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+)
+
+var _ = Describe("FileLimiter", func() {
+	var (
+		dir  string
+		path string
+		l1   *ratelimit.FileLimiter
+		l2   *ratelimit.FileLimiter
+		err  error
+	)
+	BeforeEach(func() {
+		dir, err = ioutil.TempDir("", "ratelimit")
+		Expect(err).To(Succeed())
+		path = filepath.Join(dir, "limit")
+		l1, err = ratelimit.NewFileLimiter(path, rate.Every(100*time.Millisecond), 1)
+		Expect(err).To(Succeed())
+		l2, err = ratelimit.NewFileLimiter(path, rate.Every(100*time.Millisecond), 1)
+		Expect(err).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	Context("NewFileLimiter", func() {
+		It("creates file", func() {
+			Expect(path).To(BeAnExistingFile())
+		})
+		It("returns error if file can't be created", func() {
+			_, err = ratelimit.NewFileLimiter(filepath.Join(dir, "not-found", "limit"), rate.Inf, 1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Wait", func() {
+		It("shares budget between limiters of the same file", func() {
+			start := time.Now()
+			for _, l := range []*ratelimit.FileLimiter{l1, l2, l1, l2} {
+				Expect(l.Wait(context.Background())).To(Succeed())
+			}
+			Expect(time.Since(start)).To(BeNumerically(">=", 250*time.Millisecond))
+		})
+		It("allows burst", func() {
+			l1.Burst = 3
+			start := time.Now()
+			for i := 0; i < 3; i++ {
+				Expect(l1.Wait(context.Background())).To(Succeed())
+			}
+			Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+		})
+		It("resets broken state", func() {
+			Expect(ioutil.WriteFile(path, []byte("broken"), 0o600)).To(Succeed())
+			Expect(l1.Wait(context.Background())).To(Succeed())
+			bs, err := ioutil.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(string(bs)).To(MatchRegexp(`^0 \d+ 0\n$`))
+		})
+		It("returns error when ctx is done", func() {
+			Expect(l1.Wait(context.Background())).To(Succeed())
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			Expect(l2.Wait(ctx)).To(MatchError(context.DeadlineExceeded))
+		})
+		It("returns error when limit is zero", func() {
+			l1.Limit = 0
+			Expect(l1.Wait(context.Background())).To(HaveOccurred())
+		})
+		It("doesn't wait when limit is inf", func() {
+			l1.Limit = rate.Inf
+			Expect(l1.Wait(context.Background())).To(Succeed())
+			Expect(path).To(BeAnExistingFile())
+		})
+	})
+	Context("ObserveResponse", func() {
+		It("stops all limiters until Retry-After", func() {
+			l1.ObserveResponse(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"1"}}})
+			start := time.Now()
+			Expect(l2.Wait(context.Background())).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 900*time.Millisecond))
+		})
+		It("stops at least one interval without Retry-After", func() {
+			l1.ObserveResponse(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}})
+			start := time.Now()
+			Expect(l2.Wait(context.Background())).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+		})
+		It("ignores other status", func() {
+			l1.ObserveResponse(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}})
+			start := time.Now()
+			Expect(l2.Wait(context.Background())).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package ratelimit
+
+import (
+	"os"
+)
+
+const lockSupported = false
+
+func lockFile(_ *os.File) error {
+	return ErrLockNotSupported
+}
+
+func unlockFile(_ *os.File) error {
+	return ErrLockNotSupported
+}
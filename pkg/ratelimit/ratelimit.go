@@ -0,0 +1,28 @@
+// Package ratelimit provides api.Limiter implementations.
+//
+// FileLimiter shares a token bucket between processes on the same host by the file lock.
+// AdaptiveLimiter slows down requests when the server returns TooManyRequests.
+//
+//	l, err := ratelimit.NewFileLimiter("/var/tmp/dpf.limit", api.DefaultRateLimit, api.DefaultRateBurst)
+//	cl := api.New(token, api.WithLimiter(ratelimit.NewAdaptiveLimiter(l)))
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// sleep waits d or ctx done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+)
+
+// WritableActions are actions which have request body.
+var WritableActions = []api.Action{api.ActionCreate, api.ActionUpdate, api.ActionApply}
+
+// KindInfo describes a registered kind.
+type KindInfo struct {
+	APIVersion string
+	Kind       string
+	// Actions are actions which GetPathMethod returns non-empty path.
+	Actions []api.Action
+	// PathParams are arguments of SetPathParams in order.
+	PathParams []PathParam
+	// Fields are writable fields of supported actions in WritableActions, derived from create/update/apply tags.
+	Fields map[api.Action][]Field
+}
+
+// Supports returns true, if the kind supports action.
+func (k *KindInfo) Supports(action api.Action) bool {
+	for _, a := range k.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// PathParam is an argument of SetPathParams.
+type PathParam struct {
+	// Name is the field name which the argument is set to (e.g. ZoneID).
+	// It is `param<N>` if the field is not found.
+	Name string
+	// Type is reflect.String or reflect.Int64.
+	Type reflect.Kind
+}
+
+// Field is a writable field of request body.
+type Field struct {
+	// Name is the field name of the struct.
+	Name string
+	// Key is the key of request body.
+	Key  string
+	Type reflect.Type
+	// OmitEmpty is true, if the tag has omitempty option.
+	OmitEmpty bool
+}
+
+// Groups returns registered groups (apiVersions) in order.
+func (s schemaSet) Groups() []string {
+	groups := make([]string, 0, len(s))
+	for group := range s {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Kinds returns registered kinds of apiVersion in order.
+func (s schemaSet) Kinds(apiVersion string) []string {
+	gs, ok := s[apiVersion]
+	if !ok {
+		return nil
+	}
+	kinds := make([]string, 0, len(gs.objectMap))
+	for kind := range gs.objectMap {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (s schemaSet) get(apiVersion, kind string) (apis.Spec, error) {
+	gs, ok := s[apiVersion]
+	if !ok {
+		return nil, fmt.Errorf("apiVersion `%s` is not support", apiVersion)
+	}
+	spec, ok := gs.objectMap[kind]
+	if !ok {
+		return nil, fmt.Errorf("kind value `%s` is not supported", kind)
+	}
+	return spec, nil
+}
+
+// New returns new empty object of kind, kind is compared case-sensitively.
+func (s schemaSet) New(apiVersion, kind string) (apis.Spec, error) {
+	spec, err := s.get(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := reflect.New(reflect.TypeOf(spec).Elem()).Interface().(apis.Spec)
+	if !ok {
+		return nil, fmt.Errorf("kind value `%s` is invalid", kind)
+	}
+	return obj, nil
+}
+
+// Describe returns actions, path params and writable fields of kind.
+func (s schemaSet) Describe(apiVersion, kind string) (*KindInfo, error) {
+	if _, err := s.get(apiVersion, kind); err != nil {
+		return nil, err
+	}
+	newSpec := func() apis.Spec {
+		obj, _ := s.New(apiVersion, kind)
+		return obj
+	}
+	info := &KindInfo{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		PathParams: pathParams(newSpec),
+		Fields:     map[api.Action][]Field{},
+	}
+	for _, action := range api.Actions() {
+		if hasPath(newSpec(), action) {
+			info.Actions = append(info.Actions, action)
+		}
+	}
+	for _, action := range WritableActions {
+		if !info.Supports(action) {
+			continue
+		}
+		info.Fields[action] = writableFields(reflect.TypeOf(newSpec()).Elem(), strings.ToLower(string(action)))
+	}
+	return info, nil
+}
+
+// hasPath returns true, if GetPathMethod returns non-empty path.
+func hasPath(spec apis.Spec, action api.Action) bool {
+	_, path := spec.GetPathMethod(action)
+	return path != ""
+}
+
+// pathParams finds arguments of SetPathParams by setting probe values.
+// Each argument is tried as both string and int64 by apis.PathParamsCandidates.
+func pathParams(newSpec func() apis.Spec) []PathParam {
+	for n := 1; n <= apis.MaxPathParams; n++ {
+		values := make([]string, n)
+		for i := range values {
+			// numeric and unlikely to be a default value
+			values[i] = strconv.Itoa(1000000001 + i)
+		}
+		for _, args := range apis.PathParamsCandidates(values) {
+			spec := newSpec()
+			if spec.SetPathParams(args...) != nil {
+				continue
+			}
+			params := make([]PathParam, n)
+			found := false
+			for i, arg := range args {
+				params[i] = PathParam{Name: fmt.Sprintf("param%d", i+1), Type: reflect.TypeOf(arg).Kind()}
+				if name := findField(reflect.ValueOf(spec).Elem(), arg); name != "" {
+					params[i].Name = name
+					found = true
+				}
+			}
+			// SetPathParams which ignores args doesn't set any field.
+			if found {
+				return params
+			}
+		}
+	}
+	return nil
+}
+
+// findField returns the field name which has value, embedded structs are searched.
+func findField(v reflect.Value, value interface{}) string {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			if name := findField(fv, value); name != "" {
+				return name
+			}
+			continue
+		}
+		if fv.Type() == reflect.TypeOf(value) && fv.Interface() == value {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// writableFields returns fields which have tag key, fields of embedded structs are included.
+func writableFields(t reflect.Type, tagKey string) []Field {
+	return structFields(t, tagKey, true)
+}
+
+// structFields returns fields which are marshaled by tag key, same as jsoniter.Config.
+// If onlyTagged is false, fields without tag use the field name as key.
+func structFields(t reflect.Type, tagKey string, onlyTagged bool) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup(tagKey)
+		opts := strings.Split(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && opts[0] == "" {
+			fields = append(fields, structFields(ft, tagKey, onlyTagged)...)
+			continue
+		}
+		if f.PkgPath != "" || opts[0] == "-" || (onlyTagged && (!ok || opts[0] == "")) {
+			continue
+		}
+		field := Field{Name: f.Name, Key: opts[0], Type: f.Type}
+		if field.Key == "" {
+			field.Key = f.Name
+		}
+		for _, opt := range opts[1:] {
+			if opt == "omitempty" {
+				field.OmitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,73 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+var (
+	typesMu         sync.RWMutex
+	enums           = map[reflect.Type][]interface{}{}
+	implementations = map[reflect.Type][]reflect.Type{}
+)
+
+// AddEnum registers values of enum type (e.g. zones.Type), they are used by JSON Schema.
+// All values must have the same type.
+func AddEnum(values ...interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	t := reflect.TypeOf(values[0])
+	for _, v := range values {
+		if reflect.TypeOf(v) != t {
+			panic(fmt.Sprintf("schema.AddEnum type: `%s` is mixed with `%s`", reflect.TypeOf(v), t))
+		}
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	enums[t] = append(enums[t], values...)
+}
+
+// EnumValues returns registered values of enum type.
+func EnumValues(t reflect.Type) []interface{} {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	return enums[t]
+}
+
+// AddImplementations registers implementations of interface field type (e.g. lb_domains.MonitoringPorps).
+// iface is nil pointer of the interface.
+//
+//	schema.AddImplementations((*MonitoringPorps)(nil), &MonitoringPorpsPING{}, &MonitoringPorpsTCP{})
+func AddImplementations(iface interface{}, impls ...interface{}) {
+	it := reflect.TypeOf(iface)
+	if it == nil || it.Kind() != reflect.Ptr || it.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("schema.AddImplementations iface: `%v` is not pointer of interface", it))
+	}
+	it = it.Elem()
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	for _, impl := range impls {
+		t := reflect.TypeOf(impl)
+		if !t.Implements(it) {
+			panic(fmt.Sprintf("schema.AddImplementations impl: `%s` doesn't implement `%s`", t, it))
+		}
+		implementations[it] = append(implementations[it], t)
+	}
+}
+
+// Implementations returns registered implementations of interface type.
+func Implementations(t reflect.Type) []reflect.Type {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	return implementations[t]
+}
+
+func init() {
+	AddEnum(types.Disabled, types.Enabled)
+	AddEnum(types.FavoriteHighPriority, types.FavoriteLowPriority)
+	AddEnum(types.StateBeforeStart, types.StateRunning)
+}
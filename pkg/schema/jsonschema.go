@@ -0,0 +1,246 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+// JSONSchemaDraft is $schema of generated JSON Schema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a JSON Schema document, only keywords used by the generator are defined.
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type is string or []string.
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *int64                 `json:"minimum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+}
+
+var (
+	nullablePositiveInt32Type = reflect.TypeOf(types.NullablePositiveInt32(0))
+	nullablePositiveInt64Type = reflect.TypeOf(types.NullablePositiveInt64(0))
+	typesTimeType             = reflect.TypeOf(types.Time{})
+	timeType                  = reflect.TypeOf(time.Time{})
+	ipNetType                 = reflect.TypeOf(types.IPNet{})
+	netIPNetType              = reflect.TypeOf(net.IPNet{})
+	rawMessageType            = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType         = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// BodyJSONSchema returns JSON Schema of request body of action (Create, Update or Apply).
+// Properties are derived from create/update/apply tags, same as JSONAPIAdapter.
+func (s schemaSet) BodyJSONSchema(apiVersion, kind string, action api.Action) (*JSONSchema, error) {
+	info, err := s.Describe(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := info.Fields[action]; !ok {
+		return nil, fmt.Errorf("kind value `%s` doesn't have request body of action `%s`", kind, action)
+	}
+	spec, _ := s.get(apiVersion, kind)
+	g := &jsonSchemaGenerator{tagKey: strings.ToLower(string(action)), onlyTagged: true, visiting: map[reflect.Type]bool{}}
+	res := g.typeSchema(reflect.TypeOf(spec))
+	res.Schema = JSONSchemaDraft
+	res.Title = fmt.Sprintf("%s %s request body (%s)", kind, action, apiVersion)
+	return res, nil
+}
+
+// ManifestJSONSchema returns JSON Schema of file format of kind.
+// Properties of resource are field names, same as api.MarshalOutput.
+func (s schemaSet) ManifestJSONSchema(apiVersion, kind string) (*JSONSchema, error) {
+	res, err := s.manifestJSONSchema(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	res.Schema = JSONSchemaDraft
+	return res, nil
+}
+
+// ManifestsJSONSchema returns JSON Schema which accepts file format of all registered kinds.
+func (s schemaSet) ManifestsJSONSchema() *JSONSchema {
+	res := &JSONSchema{
+		Schema: JSONSchemaDraft,
+		Title:  "manifest",
+	}
+	for _, group := range s.Groups() {
+		for _, kind := range s.Kinds(group) {
+			frame, _ := s.manifestJSONSchema(group, kind)
+			res.OneOf = append(res.OneOf, frame)
+		}
+	}
+	return res
+}
+
+func (s schemaSet) manifestJSONSchema(apiVersion, kind string) (*JSONSchema, error) {
+	spec, err := s.get(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	g := &jsonSchemaGenerator{tagKey: "json", visiting: map[reflect.Type]bool{}}
+	return &JSONSchema{
+		Title: fmt.Sprintf("%s (%s)", kind, apiVersion),
+		Type:  "object",
+		Properties: map[string]*JSONSchema{
+			"apiVersion": {Type: "string", Const: apiVersion},
+			"kind":       {Type: "string", Const: kind},
+			"resource":   g.typeSchema(reflect.TypeOf(spec)),
+		},
+		Required:             []string{"apiVersion", "kind", "resource"},
+		AdditionalProperties: false,
+	}, nil
+}
+
+type jsonSchemaGenerator struct {
+	tagKey string
+	// onlyTagged is same as OnlyTaggedField of jsoniter.Config.
+	onlyTagged bool
+	// struct types which are being generated, for recursive types.
+	visiting map[reflect.Type]bool
+}
+
+func (g *jsonSchemaGenerator) typeSchema(t reflect.Type) *JSONSchema {
+	if values := EnumValues(t); len(values) > 0 {
+		res := g.typeSchema(kindType(t.Kind()))
+		res.Enum = values
+		// zero value is marshaled when the field is not set.
+		if zero := reflect.Zero(t).Interface(); !containsValue(values, zero) {
+			res.Enum = append(append([]interface{}{}, values...), zero)
+		}
+		return res
+	}
+	switch t {
+	case nullablePositiveInt32Type, nullablePositiveInt64Type:
+		// 0 is marshaled as null.
+		return &JSONSchema{Type: []string{"integer", "null"}, Minimum: new(int64)}
+	case typesTimeType, timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case ipNetType, netIPNetType:
+		return &JSONSchema{Type: "string"}
+	case rawMessageType:
+		return &JSONSchema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &JSONSchema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.Interface:
+		res := &JSONSchema{}
+		for _, impl := range Implementations(t) {
+			res.AnyOf = append(res.AnyOf, g.typeSchema(impl))
+		}
+		return res
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string"}
+		}
+		return &JSONSchema{Type: []string{"array", "null"}, Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer", Minimum: new(int64)}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	}
+	return &JSONSchema{}
+}
+
+func (g *jsonSchemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	if g.visiting[t] {
+		return &JSONSchema{Type: "object"}
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+	res := &JSONSchema{
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: false,
+	}
+	for _, f := range structFields(t, g.tagKey, g.onlyTagged) {
+		sub := g.typeSchema(f.Type)
+		// nil pointer and interface are marshaled as null, if the field doesn't have omitempty.
+		if !f.OmitEmpty && (f.Type.Kind() == reflect.Ptr || f.Type.Kind() == reflect.Interface) {
+			sub = nullable(sub)
+		}
+		res.Properties[f.Key] = sub
+	}
+	return res
+}
+
+// nullable returns s which accepts null too.
+func nullable(s *JSONSchema) *JSONSchema {
+	switch t := s.Type.(type) {
+	case string:
+		s.Type = []string{t, "null"}
+	case []string:
+		for _, v := range t {
+			if v == "null" {
+				return s
+			}
+		}
+		s.Type = append(append([]string{}, t...), "null")
+	default:
+		if len(s.AnyOf) == 0 {
+			// no type accepts any value.
+			return s
+		}
+		s.AnyOf = append(s.AnyOf, &JSONSchema{Type: "null"})
+		return s
+	}
+	if s.Enum != nil {
+		s.Enum = append(append([]interface{}{}, s.Enum...), nil)
+	}
+	return s
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// kindType returns the basic type of kind, it is used for enum types.
+func kindType(k reflect.Kind) reflect.Type {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.TypeOf(int64(0))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.TypeOf(uint64(0))
+	case reflect.Float32, reflect.Float64:
+		return reflect.TypeOf(float64(0))
+	case reflect.Bool:
+		return reflect.TypeOf(false)
+	}
+	return reflect.TypeOf("")
+}
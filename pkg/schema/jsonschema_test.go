@@ -0,0 +1,259 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/schema"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// jsonType returns JSON Schema type of value which is unmarshaled by encoding/json.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// hasType returns true, if value is one of types of s.
+func hasType(s *schema.JSONSchema, value interface{}) bool {
+	var types []string
+	switch t := s.Type.(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	default:
+		return true
+	}
+	vt := jsonType(value)
+	for _, t := range types {
+		if t == vt || (t == "number" && vt == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// conforms returns keys of value which are not defined in JSON Schema or don't match the type.
+func conforms(s *schema.JSONSchema, value interface{}, path string) []string {
+	if len(s.AnyOf) > 0 {
+		var best []string
+		for i, sub := range s.AnyOf {
+			res := conforms(sub, value, path)
+			if i == 0 || len(res) < len(best) {
+				best = res
+			}
+		}
+		return best
+	}
+	if !hasType(s, value) {
+		return []string{path + ":" + jsonType(value)}
+	}
+	var unknown []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			sub, ok := s.Properties[key]
+			if !ok {
+				sub, ok = s.AdditionalProperties.(*schema.JSONSchema)
+			}
+			if !ok {
+				unknown = append(unknown, path+"."+key)
+				continue
+			}
+			unknown = append(unknown, conforms(sub, child, path+"."+key)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			unknown = append(unknown, conforms(s.Items, child, path+"[]")...)
+		}
+	default:
+		if s.Enum != nil {
+			bs, _ := json.Marshal(v)
+			found := false
+			for _, e := range s.Enum {
+				eb, _ := json.Marshal(e)
+				found = found || string(eb) == string(bs)
+			}
+			if !found {
+				unknown = append(unknown, path+"="+string(bs))
+			}
+		}
+	}
+	return unknown
+}
+
+func toMap(bs []byte) map[string]interface{} {
+	m := map[string]interface{}{}
+	Expect(json.Unmarshal(bs, &m)).To(Succeed())
+	return m
+}
+
+var _ = Describe("JSON Schema", func() {
+	var (
+		res *schema.JSONSchema
+		err error
+	)
+	BeforeEach(func() {
+		schema.SchemaSet = schema.NewSchemaSet()
+		schema.NewRegister("zones.api.dns-platform.jp/v1").Add(&zones.Record{}, &zones.RecordList{})
+		schema.NewRegister("lb-domains.api.dns-platform.jp/v1").Add(&lb_domains.Monitoring{}, &lb_domains.RuleMethod{})
+		schema.NewRegister("common-configs.api.dns-platform.jp/v1").Add(&common_configs.CcPrimary{}, &common_configs.CcSecTransferAcl{}, &common_configs.CcNoticeAccount{})
+	})
+	Context("BodyJSONSchema", func() {
+		When("kind is Record", func() {
+			BeforeEach(func() {
+				res, err = schema.SchemaSet.BodyJSONSchema("zones.api.dns-platform.jp/v1", "Record", api.ActionCreate)
+			})
+			It("returns properties of create tags", func() {
+				Expect(err).To(Succeed())
+				Expect(res.Schema).To(Equal(schema.JSONSchemaDraft))
+				Expect(res.Type).To(Equal("object"))
+				Expect(res.AdditionalProperties).To(Equal(false))
+				Expect(res.Properties).To(HaveLen(5))
+				Expect(res.Properties["rrtype"].Enum).To(ContainElement(zones.TypeA))
+				Expect(res.Properties["ttl"].Type).To(Equal([]string{"integer", "null"}))
+				Expect(res.Properties["rdata"].Items.Properties).To(HaveKey("value"))
+			})
+			It("accepts request body", func() {
+				r := &zones.Record{Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}}
+				bs, err := api.JSON.MarshalCreate(r)
+				Expect(err).To(Succeed())
+				Expect(conforms(res, toMap(bs), "")).To(BeEmpty())
+			})
+		})
+		When("field is interface", func() {
+			It("returns anyOf of implementations", func() {
+				res, err = schema.SchemaSet.BodyJSONSchema("lb-domains.api.dns-platform.jp/v1", "Monitoring", api.ActionCreate)
+				Expect(err).To(Succeed())
+				Expect(res.Properties["mtype"].Enum).To(Equal([]interface{}{
+					lb_domains.MonitoringMtypePing, lb_domains.MonitoringMtypeTCP, lb_domains.MonitoringMtypeHTTP, lb_domains.MonitoringMtypeStatic, lb_domains.MonitoringMtype(""),
+				}))
+				Expect(res.Properties["props"].AnyOf).To(HaveLen(5))
+				Expect(res.Properties["props"].AnyOf[4].Type).To(Equal("null"))
+
+				res, err = schema.SchemaSet.BodyJSONSchema("lb-domains.api.dns-platform.jp/v1", "RuleMethod", api.ActionCreate)
+				Expect(err).To(Succeed())
+				Expect(res.Properties["method"].AnyOf).To(HaveLen(7))
+				Expect(res.Properties["method"].AnyOf[0].Properties["mtype"].Enum).To(ContainElement(lb_domains.RuleMethodMTypeFailover))
+			})
+		})
+		When("field is types.Boolean and net.IP", func() {
+			It("returns enum and string", func() {
+				res, err = schema.SchemaSet.BodyJSONSchema("common-configs.api.dns-platform.jp/v1", "CcPrimary", api.ActionUpdate)
+				Expect(err).To(Succeed())
+				Expect(res.Properties["enabled"].Type).To(Equal("integer"))
+				Expect(res.Properties["enabled"].Enum).To(Equal([]interface{}{types.Disabled, types.Enabled}))
+				Expect(res.Properties["address"].Type).To(Equal("string"))
+			})
+		})
+		When("action doesn't have request body", func() {
+			It("returns error", func() {
+				_, err = schema.SchemaSet.BodyJSONSchema("zones.api.dns-platform.jp/v1", "Record", api.ActionApply)
+				Expect(err).To(MatchError("kind value `Record` doesn't have request body of action `Apply`"))
+				_, err = schema.SchemaSet.BodyJSONSchema("zones.api.dns-platform.jp/v1", "Record", api.ActionDelete)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		When("kind is not found", func() {
+			It("returns error", func() {
+				_, err = schema.SchemaSet.BodyJSONSchema("zones.api.dns-platform.jp/v1", "Hoge", api.ActionCreate)
+				Expect(err).To(MatchError("kind value `Hoge` is not supported"))
+			})
+		})
+	})
+	Context("ManifestJSONSchema", func() {
+		It("returns file format frame", func() {
+			res, err = schema.SchemaSet.ManifestJSONSchema("zones.api.dns-platform.jp/v1", "Record")
+			Expect(err).To(Succeed())
+			Expect(res.Schema).To(Equal(schema.JSONSchemaDraft))
+			Expect(res.Required).To(Equal([]string{"apiVersion", "kind", "resource"}))
+			Expect(res.Properties["apiVersion"].Const).To(Equal("zones.api.dns-platform.jp/v1"))
+			Expect(res.Properties["kind"].Const).To(Equal("Record"))
+			Expect(res.Properties["resource"].Properties).To(HaveKey("ZoneID"))
+			Expect(res.Properties["resource"].Properties["RRType"].Enum).To(ContainElement(zones.TypeTXT))
+		})
+		It("accepts file format", func() {
+			specs := []api.Spec{
+				&zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, ID: "r1", Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+				&lb_domains.Monitoring{
+					AttributeMeta:    lb_domains.AttributeMeta{LBDomainID: "b1"},
+					MonitoringCommon: lb_domains.MonitoringCommon{ResourceName: "m1", Name: "http", MType: lb_domains.MonitoringMtypeHTTP},
+					Props:            &lb_domains.MonitoringPorpsHTTP{Port: 443, HTTPS: true, StatusCode: []string{"200"}},
+				},
+				&common_configs.CcPrimary{AttributeMeta: common_configs.AttributeMeta{CommonConfigID: 1}, ID: 2, Address: net.ParseIP("192.168.0.1"), TsigID: 3, Enabled: types.Enabled},
+				// nil pointers are marshaled as null.
+				&lb_domains.RuleMethod{RuleAttributeMeta: lb_domains.RuleAttributeMeta{AttributeMeta: lb_domains.AttributeMeta{LBDomainID: "b1"}, RuleResourceName: "r1"}},
+				&common_configs.CcSecTransferAcl{AttributeMeta: common_configs.AttributeMeta{CommonConfigID: 1}, ID: 2},
+				&common_configs.CcNoticeAccount{AttributeMeta: common_configs.AttributeMeta{CommonConfigID: 1}, ResourceName: "n1", Name: "admin", Lang: common_configs.CcNoticeLangJA, Props: common_configs.CcNoticeProps{Mail: "admin@example.jp"}},
+			}
+			for _, s := range specs {
+				bs, err := api.MarshalOutput(s)
+				Expect(err).To(Succeed())
+				m := toMap(bs)
+				res, err = schema.SchemaSet.ManifestJSONSchema(m["apiVersion"].(string), m["kind"].(string))
+				Expect(err).To(Succeed())
+				Expect(conforms(res, m, "")).To(BeEmpty(), string(bs))
+			}
+		})
+		It("allows null for pointer fields", func() {
+			res, err = schema.SchemaSet.ManifestJSONSchema("common-configs.api.dns-platform.jp/v1", "CcSecTransferAcl")
+			Expect(err).To(Succeed())
+			Expect(res.Properties["resource"].Properties["Network"].Type).To(Equal([]string{"string", "null"}))
+			Expect(res.Properties["resource"].Properties["ID"].Type).To(Equal("integer"))
+		})
+		It("doesn't accept wrong types", func() {
+			res, err = schema.SchemaSet.ManifestJSONSchema("common-configs.api.dns-platform.jp/v1", "CcPrimary")
+			Expect(err).To(Succeed())
+			m := toMap([]byte(`{"apiVersion":"common-configs.api.dns-platform.jp/v1","kind":"CcPrimary","resource":{"CommonConfigID":"1","ID":null,"Enabled":1}}`))
+			Expect(conforms(res, m, "")).To(ConsistOf(".resource.CommonConfigID:string", ".resource.ID:null"))
+		})
+		It("returns error, if kind is not found", func() {
+			_, err = schema.SchemaSet.ManifestJSONSchema("test", "Record")
+			Expect(err).To(MatchError("apiVersion `test` is not support"))
+		})
+	})
+	Context("ManifestsJSONSchema", func() {
+		It("returns oneOf of all kinds", func() {
+			res = schema.SchemaSet.ManifestsJSONSchema()
+			Expect(res.Schema).To(Equal(schema.JSONSchemaDraft))
+			Expect(res.OneOf).To(HaveLen(7))
+			Expect(res.OneOf[0].Schema).To(BeEmpty())
+			Expect(res.OneOf[0].Properties["kind"].Const).To(Equal("CcNoticeAccount"))
+		})
+	})
+	Context("AddEnum", func() {
+		It("panics by mixed types", func() {
+			Expect(func() { schema.AddEnum(zones.TypeA, "A") }).To(Panic())
+		})
+	})
+	Context("AddImplementations", func() {
+		It("panics by invalid interface", func() {
+			Expect(func() { schema.AddImplementations(lb_domains.MonitoringPorpsPING{}) }).To(Panic())
+		})
+		It("panics by not implementation", func() {
+			Expect(func() { schema.AddImplementations((*lb_domains.MonitoringPorps)(nil), &zones.Record{}) }).To(Panic())
+		})
+	})
+})
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
@@ -86,3 +88,73 @@ func (s schemaSet) Parse(bs []byte) (apis.Spec, error) {
 	}
 	return obj, nil
 }
+
+// ParseYAML parses yaml file format, json is also accepted.
+func (s schemaSet) ParseYAML(bs []byte) (apis.Spec, error) {
+	js, err := api.YAMLToJSON(bs)
+	if err != nil {
+		return nil, err
+	}
+	return s.Parse(js)
+}
+
+// ParseYAMLStream parses `---` separated yaml documents, empty documents are skipped.
+func (s schemaSet) ParseYAMLStream(bs []byte) ([]apis.Spec, error) {
+	docs, err := api.YAMLStreamToJSON(bs)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]apis.Spec, 0, len(docs))
+	for i, doc := range docs {
+		spec, err := s.Parse(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Lookup returns new object of kind, kind is compared case-insensitively.
+// If apiVersion is empty, kind is searched in all groups,
+// and it returns error when kind exists in some groups.
+func (s schemaSet) Lookup(apiVersion, kind string) (apis.Spec, error) {
+	var (
+		found    apis.Spec
+		versions []string
+	)
+	for group, gs := range s {
+		if apiVersion != "" && group != apiVersion {
+			continue
+		}
+		for name, spec := range gs.objectMap {
+			if strings.EqualFold(name, kind) {
+				found = spec
+				versions = append(versions, group)
+			}
+		}
+	}
+	switch len(versions) {
+	case 0:
+		if apiVersion != "" {
+			if _, ok := s[apiVersion]; !ok {
+				return nil, fmt.Errorf("apiVersion `%s` is not support", apiVersion)
+			}
+		}
+		return nil, fmt.Errorf("kind value `%s` is not supported", kind)
+	case 1:
+	default:
+		sort.Strings(versions)
+		return nil, fmt.Errorf("kind value `%s` is ambiguous, apiVersion must be one of %s", kind, strings.Join(versions, ", "))
+	}
+	obj, ok := found.DeepCopyObject().(apis.Spec)
+	if !ok {
+		return nil, fmt.Errorf("kind value `%s` DeepCopyObject is invalid", kind)
+	}
+	return obj, nil
+}
+
+// KindName returns kind of s, it is the type name which is registered by Register.Add.
+func KindName(s api.Spec) string {
+	return reflect.Indirect(reflect.ValueOf(s)).Type().Name()
+}
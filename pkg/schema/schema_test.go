@@ -2,9 +2,13 @@ package schema_test
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/mimuret/golang-iij-dpf/pkg/api"
 	"github.com/mimuret/golang-iij-dpf/pkg/apis"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/common_configs"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
 	"github.com/mimuret/golang-iij-dpf/pkg/schema"
 
 	. "github.com/onsi/ginkgo"
@@ -55,6 +59,14 @@ func (t *TestSpec) SetPathParams(...interface{}) error {
 	return nil
 }
 
+type TestSpecOnly struct {
+	TestSpec
+}
+
+func (t *TestSpecOnly) DeepCopyObject() api.Object {
+	return &TestSpecOnly{TestSpec: *t.DeepCopyTestSpec()}
+}
+
 type ErrSpec struct {
 	Id string
 }
@@ -193,4 +205,243 @@ var _ = Describe("Register", func() {
 			})
 		})
 	})
+	Context("ParseYAML", func() {
+		BeforeEach(func() {
+			schema.SchemaSet = schema.NewSchemaSet()
+			schema.NewRegister("test").Add(&TestSpec{})
+		})
+		It("parses yaml", func() {
+			obj, err := schema.SchemaSet.ParseYAML([]byte("apiVersion: test\nkind: TestSpec\nresource:\n  Id: hoge\n"))
+			Expect(err).To(Succeed())
+			Expect(obj).To(Equal(&TestSpec{Id: "hoge"}))
+		})
+		It("parses json", func() {
+			obj, err := schema.SchemaSet.ParseYAML([]byte(`{"apiVersion": "test", "kind": "TestSpec", "resource": {"Id": "hoge"}}`))
+			Expect(err).To(Succeed())
+			Expect(obj).To(Equal(&TestSpec{Id: "hoge"}))
+		})
+		It("returns error, if yaml is invalid", func() {
+			_, err := schema.SchemaSet.ParseYAML([]byte("apiVersion: [test"))
+			Expect(err).To(HaveOccurred())
+			_, err = schema.SchemaSet.ParseYAML([]byte("apiVersion: test\nkind: hoge\n"))
+			Expect(err).To(MatchError("kind value `hoge` is not supported"))
+		})
+	})
+	Context("ParseYAMLStream", func() {
+		BeforeEach(func() {
+			schema.SchemaSet = schema.NewSchemaSet()
+			schema.NewRegister("test").Add(&TestSpec{})
+		})
+		It("parses all documents", func() {
+			specs, err := schema.SchemaSet.ParseYAMLStream([]byte(`---
+apiVersion: test
+kind: TestSpec
+resource:
+  Id: hoge
+---
+---
+apiVersion: test
+kind: TestSpec
+resource:
+  Id: fuga
+`))
+			Expect(err).To(Succeed())
+			Expect(specs).To(Equal([]apis.Spec{&TestSpec{Id: "hoge"}, &TestSpec{Id: "fuga"}}))
+		})
+		It("returns error with document number", func() {
+			_, err := schema.SchemaSet.ParseYAMLStream([]byte("apiVersion: test\nkind: TestSpec\n---\napiVersion: test\nkind: hoge\n"))
+			Expect(err).To(MatchError("document 2: kind value `hoge` is not supported"))
+		})
+	})
+	Context("Lookup", func() {
+		var (
+			set *schema.Register
+			obj apis.Spec
+			err error
+		)
+		BeforeEach(func() {
+			schema.SchemaSet = schema.NewSchemaSet()
+			set = schema.NewRegister("test")
+			set.Add(&TestSpec{})
+			schema.NewRegister("test2").Add(&TestSpec{})
+			schema.NewRegister("test3").Add(&TestSpecOnly{})
+		})
+		When("kind exists in one group", func() {
+			BeforeEach(func() {
+				obj, err = schema.SchemaSet.Lookup("", "testspeconly")
+			})
+			It("returns new object", func() {
+				Expect(err).To(Succeed())
+				Expect(obj).To(Equal(&TestSpecOnly{}))
+			})
+		})
+		When("kind exists in some groups", func() {
+			BeforeEach(func() {
+				obj, err = schema.SchemaSet.Lookup("", "TestSpec")
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("kind value `TestSpec` is ambiguous, apiVersion must be one of test, test2"))
+			})
+		})
+		When("apiVersion is specified", func() {
+			BeforeEach(func() {
+				obj, err = schema.SchemaSet.Lookup("test2", "TestSpec")
+			})
+			It("returns new object", func() {
+				Expect(err).To(Succeed())
+				Expect(obj).To(BeAssignableToTypeOf(&TestSpec{}))
+			})
+		})
+		When("apiVersion is not found", func() {
+			BeforeEach(func() {
+				obj, err = schema.SchemaSet.Lookup("testtest", "TestSpec")
+			})
+			It("returns error", func() {
+				Expect(err).To(MatchError("apiVersion `testtest` is not support"))
+			})
+		})
+		When("kind is not found", func() {
+			BeforeEach(func() {
+				obj, err = schema.SchemaSet.Lookup("", "hogehoge")
+			})
+			It("returns error", func() {
+				Expect(err).To(MatchError("kind value `hogehoge` is not supported"))
+			})
+		})
+	})
+	Context("KindName", func() {
+		It("returns registered kind", func() {
+			Expect(schema.KindName(&TestSpecOnly{})).To(Equal("TestSpecOnly"))
+		})
+	})
+	Context("introspection", func() {
+		var (
+			obj  apis.Spec
+			info *schema.KindInfo
+			err  error
+		)
+		BeforeEach(func() {
+			schema.SchemaSet = schema.NewSchemaSet()
+			schema.NewRegister("test").Add(&TestSpec{}, &zones.Record{}, &common_configs.CcPrimary{})
+			schema.NewRegister("test2").Add(&lb_domains.RuleMethod{}, &zones.RecordList{})
+		})
+		Context("Groups", func() {
+			It("returns groups in order", func() {
+				Expect(schema.SchemaSet.Groups()).To(Equal([]string{"test", "test2"}))
+			})
+		})
+		Context("Kinds", func() {
+			It("returns kinds in order", func() {
+				Expect(schema.SchemaSet.Kinds("test")).To(Equal([]string{"CcPrimary", "Record", "TestSpec"}))
+			})
+			It("returns nil, if apiVersion is not found", func() {
+				Expect(schema.SchemaSet.Kinds("testtest")).To(BeNil())
+			})
+		})
+		Context("New", func() {
+			It("returns new empty object", func() {
+				obj, err = schema.SchemaSet.New("test", "Record")
+				Expect(err).To(Succeed())
+				Expect(obj).To(Equal(&zones.Record{}))
+			})
+			It("returns error, if kind is not found", func() {
+				_, err = schema.SchemaSet.New("test", "record")
+				Expect(err).To(MatchError("kind value `record` is not supported"))
+			})
+			It("returns error, if apiVersion is not found", func() {
+				_, err = schema.SchemaSet.New("testtest", "Record")
+				Expect(err).To(MatchError("apiVersion `testtest` is not support"))
+			})
+		})
+		Context("Describe", func() {
+			When("kind is Record", func() {
+				BeforeEach(func() {
+					info, err = schema.SchemaSet.Describe("test", "Record")
+				})
+				It("returns actions", func() {
+					Expect(err).To(Succeed())
+					Expect(info.APIVersion).To(Equal("test"))
+					Expect(info.Kind).To(Equal("Record"))
+					Expect(info.Actions).To(Equal([]api.Action{api.ActionCreate, api.ActionRead, api.ActionUpdate, api.ActionDelete, api.ActionCancel}))
+					Expect(info.Supports(api.ActionApply)).To(BeFalse())
+				})
+				It("returns path params", func() {
+					Expect(info.PathParams).To(Equal([]schema.PathParam{
+						{Name: "ZoneID", Type: reflect.String},
+						{Name: "ID", Type: reflect.String},
+					}))
+				})
+				It("returns writable fields of supported actions", func() {
+					keys := func(fields []schema.Field) []string {
+						var res []string
+						for _, f := range fields {
+							res = append(res, f.Key)
+						}
+						return res
+					}
+					Expect(info.Fields).To(HaveLen(2))
+					Expect(keys(info.Fields[api.ActionCreate])).To(Equal([]string{"name", "ttl", "rrtype", "rdata", "description"}))
+					Expect(keys(info.Fields[api.ActionUpdate])).To(Equal([]string{"ttl", "rdata", "description"}))
+					Expect(info.Fields[api.ActionCreate][2]).To(Equal(schema.Field{Name: "RRType", Key: "rrtype", Type: reflect.TypeOf(zones.TypeA)}))
+				})
+			})
+			When("path params are int64", func() {
+				BeforeEach(func() {
+					info, err = schema.SchemaSet.Describe("test", "CcPrimary")
+				})
+				It("returns path params", func() {
+					Expect(err).To(Succeed())
+					Expect(info.PathParams).To(Equal([]schema.PathParam{
+						{Name: "CommonConfigID", Type: reflect.Int64},
+						{Name: "ID", Type: reflect.Int64},
+					}))
+				})
+			})
+			When("path needs unset value", func() {
+				BeforeEach(func() {
+					info, err = schema.SchemaSet.Describe("test2", "RuleMethod")
+				})
+				It("returns actions and path params", func() {
+					Expect(err).To(Succeed())
+					Expect(info.Actions).To(Equal([]api.Action{api.ActionCreate, api.ActionRead, api.ActionUpdate, api.ActionDelete}))
+					Expect(info.PathParams).To(Equal([]schema.PathParam{
+						{Name: "LBDomainID", Type: reflect.String},
+						{Name: "RuleResourceName", Type: reflect.String},
+						{Name: "param3", Type: reflect.String},
+					}))
+					Expect(info.Fields[api.ActionCreate][1].OmitEmpty).To(BeFalse())
+					Expect(info.Fields[api.ActionCreate][0].OmitEmpty).To(BeTrue())
+				})
+			})
+			When("SetPathParams ignores args", func() {
+				BeforeEach(func() {
+					info, err = schema.SchemaSet.Describe("test", "TestSpec")
+				})
+				It("returns no path params", func() {
+					Expect(err).To(Succeed())
+					Expect(info.PathParams).To(BeEmpty())
+					Expect(info.Fields[api.ActionApply]).To(BeEmpty())
+				})
+			})
+			When("kind is list", func() {
+				BeforeEach(func() {
+					info, err = schema.SchemaSet.Describe("test2", "RecordList")
+				})
+				It("returns list actions", func() {
+					Expect(err).To(Succeed())
+					Expect(info.Actions).To(Equal([]api.Action{api.ActionList, api.ActionCount}))
+					Expect(info.PathParams).To(Equal([]schema.PathParam{{Name: "ZoneID", Type: reflect.String}}))
+				})
+			})
+			When("kind is not found", func() {
+				BeforeEach(func() {
+					info, err = schema.SchemaSet.Describe("test", "hogehoge")
+				})
+				It("returns error", func() {
+					Expect(err).To(MatchError("kind value `hogehoge` is not supported"))
+				})
+			})
+		})
+	})
 })
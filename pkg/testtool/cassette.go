@@ -0,0 +1,218 @@
+package testtool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+)
+
+var _ http.RoundTripper = &Cassette{}
+
+// redactHeaders are request headers whose value is not saved in cassette.
+var redactHeaders = []string{"Authorization"}
+
+type CassetteMode int
+
+const (
+	// CassetteRecord sends requests by the transport and records interactions.
+	CassetteRecord CassetteMode = iota
+	// CassetteReplay returns recorded responses without sending requests.
+	CassetteReplay
+)
+
+// Cassette is http.RoundTripper which records API interactions and replays them.
+// It is set by api.Client.SetRoundTripper.
+//
+//	// record
+//	c := testtool.NewCassetteRecorder("testdata/zones.json", nil)
+//	cl.SetRoundTripper(c)
+//	...
+//	err := c.Save()
+//
+//	// replay
+//	c, err := testtool.LoadCassette("testdata/zones.json")
+//	cl.SetRoundTripper(c)
+//
+// Authorization header and secret attributes of bodies (see api.RedactSecrets) are not saved.
+// Requests are matched by method, path with query and body.
+// The endpoint host is not matched, so cassette can be replayed with any endpoint.
+type Cassette struct {
+	Path         string         `json:"-"`
+	Mode         CassetteMode   `json:"-"`
+	Interactions []*Interaction `json:"interactions"`
+
+	transport http.RoundTripper
+	mu        sync.Mutex
+	used      []bool
+}
+
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// NewCassetteRecorder returns Cassette of record mode.
+// If rt is nil, http.DefaultTransport is used.
+func NewCassetteRecorder(path string, rt http.RoundTripper) *Cassette {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &Cassette{
+		Path:      path,
+		Mode:      CassetteRecord,
+		transport: rt,
+	}
+}
+
+// LoadCassette returns Cassette of replay mode from path.
+func LoadCassette(path string) (*Cassette, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	c := &Cassette{Path: path, Mode: CassetteReplay}
+	if err := json.Unmarshal(bs, c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+	c.used = make([]bool, len(c.Interactions))
+	return c, nil
+}
+
+// Save writes recorded interactions to Path.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bs, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := ioutil.WriteFile(c.Path, append(bs, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Unplayed returns interactions which are not replayed yet.
+func (c *Cassette) Unplayed() []*Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var res []*Interaction
+	for i, interaction := range c.Interactions {
+		if !c.used[i] {
+			res = append(res, interaction)
+		}
+	}
+	return res
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	creq, err := newCassetteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.Mode == CassetteReplay {
+		return c.replay(req, creq)
+	}
+	return c.record(req, creq)
+}
+
+func newCassetteRequest(req *http.Request) (CassetteRequest, error) {
+	creq := CassetteRequest{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Header: req.Header.Clone(),
+	}
+	for _, key := range redactHeaders {
+		if creq.Header.Get(key) != "" {
+			creq.Header.Set(key, api.RedactedValue)
+		}
+	}
+	if req.Body != nil {
+		bs, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return creq, fmt.Errorf("failed to read request body: %w", err)
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(bs))
+		// the body is redacted on both record and replay, so it still matches.
+		creq.Body = api.RedactSecrets(string(bs))
+	}
+	return creq, nil
+}
+
+func (c *Cassette) record(req *http.Request, creq CassetteRequest) (*http.Response, error) {
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, &Interaction{
+		Request: creq,
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       api.RedactSecrets(string(bs)),
+		},
+	})
+	c.used = append(c.used, true)
+	return resp, nil
+}
+
+// replay returns the first unplayed interaction which matches the request.
+func (c *Cassette) replay(req *http.Request, creq CassetteRequest) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, interaction := range c.Interactions {
+		if c.used[i] || !interaction.Request.match(creq) {
+			continue
+		}
+		c.used[i] = true
+		res := interaction.Response
+		header := res.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+			StatusCode:    res.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(res.Body)),
+			ContentLength: int64(len(res.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("unexpected request %s %s by cassette %s", creq.Method, creq.URL, c.Path)
+}
+
+func (r CassetteRequest) match(o CassetteRequest) bool {
+	return r.Method == o.Method && r.URL == o.URL && r.Body == o.Body
+}
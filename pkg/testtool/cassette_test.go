@@ -0,0 +1,158 @@
+package testtool_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+)
+
+var _ = Describe("Cassette", func() {
+	var (
+		fs   *testtool.FakeServer
+		srv  *httptest.Server
+		cl   *api.Client
+		ctx  context.Context
+		dir  string
+		path string
+		err  error
+	)
+	record := &zones.Record{
+		AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+		Name:          "www.example.jp.",
+		TTL:           300,
+		RRType:        zones.TypeA,
+		RData:         zones.RecordRDATASlice{{Value: "192.168.0.1"}},
+	}
+	scenario := func() (*core.Zone, error) {
+		zone := &core.Zone{ID: "m1"}
+		if _, err := cl.Read(ctx, zone); err != nil {
+			return nil, err
+		}
+		if _, _, err := apiutils.SyncCreate(ctx, cl, record.DeepCopy(), nil); err != nil {
+			return nil, err
+		}
+		return zone, nil
+	}
+	BeforeEach(func() {
+		ctx = context.Background()
+		dir, err = ioutil.TempDir("", "cassette")
+		Expect(err).To(Succeed())
+		path = filepath.Join(dir, "cassette.json")
+		fs = testtool.NewFakeServer()
+		fs.Token = "secret-token"
+		fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."})
+		srv = httptest.NewServer(fs)
+		cl = api.NewClient("secret-token", srv.URL, nil)
+		rec := testtool.NewCassetteRecorder(path, api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+		cl.SetRoundTripper(rec)
+		_, err = scenario()
+		Expect(err).To(Succeed())
+		Expect(rec.Interactions).To(HaveLen(3))
+		Expect(rec.Save()).To(Succeed())
+		srv.Close()
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	Context("record", func() {
+		It("redacts token", func() {
+			bs, err := ioutil.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(string(bs)).NotTo(ContainSubstring("secret-token"))
+			Expect(string(bs)).To(ContainSubstring(api.RedactedValue))
+		})
+	})
+	Context("replay", func() {
+		var c *testtool.Cassette
+		BeforeEach(func() {
+			c, err = testtool.LoadCassette(path)
+			Expect(err).To(Succeed())
+			cl = api.NewClient("other-token", "http://localhost.invalid", nil)
+			cl.SetRoundTripper(c)
+		})
+		It("returns recorded responses", func() {
+			zone, err := scenario()
+			Expect(err).To(Succeed())
+			Expect(zone.Name).To(Equal("example.jp."))
+			Expect(c.Unplayed()).To(BeEmpty())
+		})
+		It("returns error for unexpected request", func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m2"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unexpected request GET /zones/m2"))
+			Expect(c.Unplayed()).To(HaveLen(3))
+		})
+		It("returns error for different body", func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(err).To(Succeed())
+			r := record.DeepCopy()
+			r.TTL = 600
+			_, err = cl.Create(ctx, r, nil)
+			Expect(err).To(HaveOccurred())
+		})
+		It("returns error when interactions are used up", func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(err).To(Succeed())
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("LoadCassette", func() {
+		It("returns error for not exist file", func() {
+			_, err = testtool.LoadCassette(filepath.Join(dir, "not-exist.json"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Cassette with secret", func() {
+	var (
+		srv  *httptest.Server
+		dir  string
+		path string
+		tsig *contracts.Tsig
+		err  error
+	)
+	BeforeEach(func() {
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"request_id":"REQ1","result":{"id":1,"name":"tsig1","algorithm":0,"secret":"c2VjcmV0","description":""}}`))
+		}))
+		dir, err = ioutil.TempDir("", "cassette")
+		Expect(err).To(Succeed())
+		path = filepath.Join(dir, "cassette.json")
+		cl := api.NewClient("token", srv.URL, nil)
+		rec := testtool.NewCassetteRecorder(path, api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+		cl.SetRoundTripper(rec)
+		tsig = &contracts.Tsig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, ID: 1}
+		_, err = cl.Read(context.Background(), tsig)
+		Expect(err).To(Succeed())
+		Expect(rec.Save()).To(Succeed())
+	})
+	AfterEach(func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	})
+	It("returns the secret to the client", func() {
+		Expect(tsig.Secret).To(Equal("c2VjcmV0"))
+	})
+	It("redacts the secret in the file", func() {
+		bs, err := ioutil.ReadFile(path)
+		Expect(err).To(Succeed())
+		Expect(string(bs)).NotTo(ContainSubstring("c2VjcmV0"))
+		Expect(string(bs)).To(ContainSubstring(api.RedactedValue))
+	})
+})
@@ -0,0 +1,365 @@
+package testtool
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+)
+
+var _ http.Handler = &FakeServer{}
+
+// FakeServer is stateful fake of DPF API for testing with real api.Client.
+// It implements zones, records, record diffs, default ttl, zone apply/cancel,
+// zone histories, jobs, contracts, common configs and lb_domains config.
+// Non GET requests return jobs_url, and the change is made when the job becomes SUCCESSFUL.
+//
+//	fs := testtool.NewFakeServer()
+//	fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."})
+//	srv := httptest.NewServer(fs)
+//	defer srv.Close()
+//	cl := api.NewClient("token", srv.URL, nil)
+type FakeServer struct {
+	// If Token is not empty, requests with other token return auth error.
+	Token string
+	// JobPolls is the number of job reads which return RUNNING before the job is finished.
+	// Until then, GET requests return the state before the change.
+	JobPolls int
+
+	mu        sync.Mutex
+	routes    []fakeRoute
+	seq       int64
+	errors    map[string][]*api.BadResponse
+	jobFail   *core.Job
+	jobs      map[string]*fakeJob
+	zones     map[string]*fakeZone
+	zoneIDs   []string
+	contracts map[string]*fakeContract
+	contIDs   []string
+	lbDomains map[string]*fakeLBDomain
+	lbIDs     []string
+}
+
+type fakeRoute struct {
+	method  string
+	pattern *regexp.Regexp
+	handle  func(w http.ResponseWriter, r *http.Request, args []string)
+}
+
+type fakeJob struct {
+	job   core.Job
+	polls int
+	// apply makes the change of the job, it is called once when the job becomes SUCCESSFUL.
+	apply func()
+}
+
+type fakeResponse struct {
+	RequestID string      `read:"request_id"`
+	Result    interface{} `read:"result,omitempty"`
+	Results   interface{} `read:"results,omitempty"`
+	JobsURL   string      `read:"jobs_url,omitempty"`
+}
+
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{
+		errors:    map[string][]*api.BadResponse{},
+		jobs:      map[string]*fakeJob{},
+		zones:     map[string]*fakeZone{},
+		contracts: map[string]*fakeContract{},
+		lbDomains: map[string]*fakeLBDomain{},
+	}
+	f.handle(http.MethodGet, `/jobs/([^/]+)`, f.readJob)
+	f.zoneRoutes()
+	f.contractRoutes()
+	f.lbDomainRoutes()
+	return f
+}
+
+func (f *FakeServer) handle(method, pattern string, h func(w http.ResponseWriter, r *http.Request, args []string)) {
+	f.routes = append(f.routes, fakeRoute{
+		method:  method,
+		pattern: regexp.MustCompile("^" + pattern + "$"),
+		handle:  h,
+	})
+}
+
+// InjectError makes the next request of method and path return res.
+// res.StatusCode is used as http status code.
+func (f *FakeServer) InjectError(method, path string, res *api.BadResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := method + " " + path
+	f.errors[key] = append(f.errors[key], res)
+}
+
+// FailNextJob makes the next non GET request be accepted without changing state,
+// and its job returns FAILED with errorType and errorMessage.
+func (f *FakeServer) FailNextJob(errorType, errorMessage string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobFail = &core.Job{
+		Status:       core.JobStatusFailed,
+		ErrorType:    errorType,
+		ErrorMessage: errorMessage,
+	}
+}
+
+func (f *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if f.Token != "" && r.Header.Get("Authorization") != "Bearer "+f.Token {
+		f.writeError(w, &api.BadResponse{
+			StatusCode:   http.StatusBadRequest,
+			ErrorType:    api.ErrorTypeParamaterError,
+			ErrorMessage: "Invalid access token.",
+			ErrorDetails: api.ErrorDetails{{Code: "invalid", Attribute: "access_token"}},
+		})
+		return
+	}
+	key := r.Method + " " + r.URL.Path
+	if errs := f.errors[key]; len(errs) > 0 {
+		f.errors[key] = errs[1:]
+		f.writeError(w, errs[0])
+		return
+	}
+	for _, route := range f.routes {
+		args := route.pattern.FindStringSubmatch(r.URL.Path)
+		if route.method != r.Method || args == nil {
+			continue
+		}
+		if r.Method != http.MethodGet && f.jobFail != nil {
+			job := *f.jobFail
+			f.jobFail = nil
+			f.writeJob(w, r, job, nil)
+			return
+		}
+		route.handle(w, r, args[1:])
+		return
+	}
+	f.writeNotFound(w)
+}
+
+func (f *FakeServer) nextID() int64 {
+	f.seq++
+	return f.seq
+}
+
+func (f *FakeServer) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	bs, err := api.JSON.Read.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, `{"request_id": "%s", "error_type": "%s", "error_message": "%s"}`, GenReqID(), api.ErrorTypeSystemError, err)
+		return
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(bs)
+}
+
+func (f *FakeServer) writeError(w http.ResponseWriter, res *api.BadResponse) {
+	e := *res
+	if e.RequestID == "" {
+		e.RequestID = GenReqID()
+	}
+	status := e.StatusCode
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+	f.writeJSON(w, status, &e)
+}
+
+func (f *FakeServer) writeNotFound(w http.ResponseWriter) {
+	f.writeError(w, &api.BadResponse{
+		StatusCode:   http.StatusNotFound,
+		ErrorType:    api.ErrorTypeNotFound,
+		ErrorMessage: "Specified resource not found.",
+	})
+}
+
+func (f *FakeServer) writeInvalid(w http.ResponseWriter, message string, attributes ...string) {
+	res := &api.BadResponse{
+		StatusCode:   http.StatusBadRequest,
+		ErrorType:    api.ErrorTypeParamaterError,
+		ErrorMessage: message,
+	}
+	for _, attr := range attributes {
+		res.ErrorDetails = append(res.ErrorDetails, api.ErrorDetail{Code: "invalid", Attribute: attr})
+	}
+	f.writeError(w, res)
+}
+
+func (f *FakeServer) writeResult(w http.ResponseWriter, result interface{}) {
+	f.writeJSON(w, http.StatusOK, &fakeResponse{RequestID: GenReqID(), Result: result})
+}
+
+// writeList writes list or count response of items.
+// items must be slice, it is filtered by keywords and paginated by offset and limit.
+func (f *FakeServer) writeList(w http.ResponseWriter, r *http.Request, items interface{}, count bool) {
+	q := r.URL.Query()
+	v := reflect.ValueOf(items)
+	filtered := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		match, err := matchKeywords(v.Index(i).Interface(), q)
+		if err != nil {
+			f.writeInvalid(w, err.Error(), "keywords")
+			return
+		}
+		if match {
+			filtered = reflect.Append(filtered, v.Index(i))
+		}
+	}
+	if count {
+		f.writeResult(w, &api.Count{Count: int32(filtered.Len())})
+		return
+	}
+	offset, limit := 0, 100
+	if s := q.Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			f.writeInvalid(w, "Invalid offset.", "offset")
+			return
+		}
+		offset = n
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 10000 {
+			f.writeInvalid(w, "Invalid limit.", "limit")
+			return
+		}
+		limit = n
+	}
+	if offset > filtered.Len() {
+		offset = filtered.Len()
+	}
+	end := offset + limit
+	if end > filtered.Len() {
+		end = filtered.Len()
+	}
+	f.writeJSON(w, http.StatusOK, &fakeResponse{RequestID: GenReqID(), Results: filtered.Slice(offset, end).Interface()})
+}
+
+var keywordParam = regexp.MustCompile(`^_keywords_(.+)\[\]$`)
+
+// matchKeywords returns true, if item matches _keywords_<attribute>[] params.
+// String values are partial match, full text search is not supported.
+func matchKeywords(item interface{}, q map[string][]string) (bool, error) {
+	var attrs map[string]interface{}
+	groups := 0
+	matched := 0
+	for key, values := range q {
+		m := keywordParam.FindStringSubmatch(key)
+		if m == nil || m[1] == "full_text" {
+			continue
+		}
+		if attrs == nil {
+			bs, err := api.JSON.Read.Marshal(item)
+			if err != nil {
+				return false, err
+			}
+			if err := api.UnmarshalRead(bs, &attrs); err != nil {
+				return false, err
+			}
+		}
+		attr, ok := attrs[m[1]]
+		if !ok {
+			return false, fmt.Errorf("unknown keyword %s", m[1])
+		}
+		groups++
+		for _, value := range values {
+			if strings.Contains(fmt.Sprint(attr), value) {
+				matched++
+				break
+			}
+		}
+	}
+	if groups == 0 {
+		return true, nil
+	}
+	if len(q["type"]) > 0 && q["type"][0] == string(api.SearchTypeOR) {
+		return matched > 0, nil
+	}
+	return matched == groups, nil
+}
+
+func (f *FakeServer) baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// writeAsync creates successful job and writes async response.
+// resourcePath is set to resources_url of the job.
+// apply is called when the job becomes SUCCESSFUL, see JobPolls.
+func (f *FakeServer) writeAsync(w http.ResponseWriter, r *http.Request, resourcePath string, apply func()) {
+	job := core.Job{Status: core.JobStatusSuccessful}
+	if resourcePath != "" {
+		job.ResourceUrl = f.baseURL(r) + resourcePath
+	}
+	f.writeJob(w, r, job, apply)
+}
+
+func (f *FakeServer) writeJob(w http.ResponseWriter, r *http.Request, job core.Job, apply func()) {
+	job.RequestID = GenReqID()
+	j := &fakeJob{job: job, apply: apply}
+	f.jobs[job.RequestID] = j
+	if f.JobPolls <= 0 {
+		j.finish()
+	}
+	f.writeJSON(w, http.StatusAccepted, &fakeResponse{
+		RequestID: job.RequestID,
+		JobsURL:   f.baseURL(r) + "/jobs/" + job.RequestID,
+	})
+}
+
+func (f *FakeServer) readJob(w http.ResponseWriter, r *http.Request, args []string) {
+	j, ok := f.jobs[args[0]]
+	if !ok {
+		f.writeNotFound(w)
+		return
+	}
+	job := j.job
+	if j.polls < f.JobPolls {
+		j.polls++
+		job.Status = core.JobStatusRunning
+		job.ResourceUrl = ""
+		job.ErrorType = ""
+		job.ErrorMessage = ""
+	} else {
+		j.finish()
+	}
+	f.writeResult(w, &job)
+}
+
+func (j *fakeJob) finish() {
+	if j.apply != nil && j.job.Status == core.JobStatusSuccessful {
+		j.apply()
+	}
+	j.apply = nil
+}
+
+// decodeBody decodes request body by json tag of the action.
+func decodeBody(r *http.Request, action api.Action, v interface{}) error {
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case api.ActionCreate:
+		return api.JSON.Create.Unmarshal(bs, v)
+	case api.ActionUpdate:
+		return api.JSON.Update.Unmarshal(bs, v)
+	case api.ActionApply:
+		return api.JSON.Apply.Unmarshal(bs, v)
+	}
+	return api.UnmarshalRead(bs, v)
+}
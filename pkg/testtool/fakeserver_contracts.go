@@ -0,0 +1,285 @@
+package testtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+type fakeContract struct {
+	contract      core.Contract
+	commonConfigs []contracts.CommonConfig
+}
+
+type fakeLBDomain struct {
+	lbDomain core.LBDomain
+	// config is stored as apply request body
+	config json.RawMessage
+}
+
+// AddContract adds contract and its common configs to the server.
+// If ID of common config is 0, it is generated.
+// If there is no default common config, the first one is default.
+func (f *FakeServer) AddContract(contract core.Contract, commonConfigs ...contracts.CommonConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := &fakeContract{contract: contract}
+	hasDefault := false
+	for _, cc := range commonConfigs {
+		if cc.ID == 0 {
+			cc.ID = f.nextCommonConfigID(c)
+		}
+		cc.ContractID = contract.ID
+		hasDefault = hasDefault || cc.Default == types.Enabled
+		c.commonConfigs = append(c.commonConfigs, cc)
+	}
+	if !hasDefault && len(c.commonConfigs) > 0 {
+		c.commonConfigs[0].Default = types.Enabled
+	}
+	if _, ok := f.contracts[contract.ID]; !ok {
+		f.contIDs = append(f.contIDs, contract.ID)
+	}
+	f.contracts[contract.ID] = c
+}
+
+// AddLBDomain adds lb domain with empty config to the server.
+func (f *FakeServer) AddLBDomain(lbDomain core.LBDomain) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.lbDomains[lbDomain.ID]; !ok {
+		f.lbIDs = append(f.lbIDs, lbDomain.ID)
+	}
+	f.lbDomains[lbDomain.ID] = &fakeLBDomain{
+		lbDomain: lbDomain,
+		config:   json.RawMessage(`{"monitorings":[],"sites":[],"rules":[]}`),
+	}
+}
+
+func (f *FakeServer) nextCommonConfigID(c *fakeContract) int64 {
+	for {
+		id := f.nextID()
+		exist := false
+		for _, cc := range c.commonConfigs {
+			exist = exist || cc.ID == id
+		}
+		if !exist {
+			return id
+		}
+	}
+}
+
+func (f *FakeServer) contractRoutes() {
+	f.handle(http.MethodGet, `/contracts(/count)?`, f.listContracts)
+	f.handle(http.MethodGet, `/contracts/([^/]+)`, f.withContract(f.readContract))
+	f.handle(http.MethodPatch, `/contracts/([^/]+)`, f.withContract(f.updateContract))
+	f.handle(http.MethodGet, `/contracts/([^/]+)/common_configs(/count)?`, f.withContract(f.listCommonConfigs))
+	f.handle(http.MethodPost, `/contracts/([^/]+)/common_configs`, f.withContract(f.createCommonConfig))
+	f.handle(http.MethodPatch, `/contracts/([^/]+)/common_configs/default`, f.withContract(f.applyDefaultCommonConfig))
+	f.handle(http.MethodGet, `/contracts/([^/]+)/common_configs/([0-9]+)`, f.withContract(f.withCommonConfig(f.readCommonConfig)))
+	f.handle(http.MethodPatch, `/contracts/([^/]+)/common_configs/([0-9]+)`, f.withContract(f.withCommonConfig(f.updateCommonConfig)))
+	f.handle(http.MethodDelete, `/contracts/([^/]+)/common_configs/([0-9]+)`, f.withContract(f.withCommonConfig(f.deleteCommonConfig)))
+}
+
+func (f *FakeServer) withContract(h func(w http.ResponseWriter, r *http.Request, c *fakeContract, args []string)) func(w http.ResponseWriter, r *http.Request, args []string) {
+	return func(w http.ResponseWriter, r *http.Request, args []string) {
+		c, ok := f.contracts[args[0]]
+		if !ok {
+			f.writeNotFound(w)
+			return
+		}
+		h(w, r, c, args[1:])
+	}
+}
+
+func (f *FakeServer) withCommonConfig(h func(w http.ResponseWriter, r *http.Request, c *fakeContract, i int)) func(w http.ResponseWriter, r *http.Request, c *fakeContract, args []string) {
+	return func(w http.ResponseWriter, r *http.Request, c *fakeContract, args []string) {
+		id, _ := strconv.ParseInt(args[0], 10, 64)
+		i := c.commonConfigIndex(id)
+		if i < 0 {
+			f.writeNotFound(w)
+			return
+		}
+		h(w, r, c, i)
+	}
+}
+
+// commonConfigIndex returns index of the common config, or -1 if it is not found.
+func (c *fakeContract) commonConfigIndex(id int64) int {
+	for i := range c.commonConfigs {
+		if c.commonConfigs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *FakeServer) listContracts(w http.ResponseWriter, r *http.Request, args []string) {
+	items := []core.Contract{}
+	for _, id := range f.contIDs {
+		items = append(items, f.contracts[id].contract)
+	}
+	f.writeList(w, r, items, args[0] != "")
+}
+
+func (f *FakeServer) readContract(w http.ResponseWriter, r *http.Request, c *fakeContract, _ []string) {
+	f.writeResult(w, &c.contract)
+}
+
+func (f *FakeServer) updateContract(w http.ResponseWriter, r *http.Request, c *fakeContract, _ []string) {
+	contract := c.contract
+	if err := decodeBody(r, api.ActionUpdate, &contract); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	f.writeAsync(w, r, "/contracts/"+contract.ID, func() { c.contract = contract })
+}
+
+func (f *FakeServer) listCommonConfigs(w http.ResponseWriter, r *http.Request, c *fakeContract, args []string) {
+	items := append([]contracts.CommonConfig{}, c.commonConfigs...)
+	f.writeList(w, r, items, args[0] != "")
+}
+
+func (f *FakeServer) createCommonConfig(w http.ResponseWriter, r *http.Request, c *fakeContract, _ []string) {
+	cc := contracts.CommonConfig{}
+	if err := decodeBody(r, api.ActionCreate, &cc); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	if cc.Name == "" {
+		f.writeInvalid(w, "Name is required.", "name")
+		return
+	}
+	cc.ID = f.nextCommonConfigID(c)
+	cc.ContractID = c.contract.ID
+	f.writeAsync(w, r, fmt.Sprintf("/contracts/%s/common_configs/%d", c.contract.ID, cc.ID), func() {
+		cc.Default = types.Disabled
+		if len(c.commonConfigs) == 0 {
+			cc.Default = types.Enabled
+		}
+		c.commonConfigs = append(c.commonConfigs, cc)
+	})
+}
+
+func (f *FakeServer) applyDefaultCommonConfig(w http.ResponseWriter, r *http.Request, c *fakeContract, _ []string) {
+	d := contracts.CommonConfigDefault{}
+	if err := decodeBody(r, api.ActionApply, &d); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	found := false
+	for i := range c.commonConfigs {
+		found = found || c.commonConfigs[i].ID == d.ID
+	}
+	if !found {
+		f.writeInvalid(w, "Common config not found.", "common_config_id")
+		return
+	}
+	f.writeAsync(w, r, fmt.Sprintf("/contracts/%s/common_configs/%d", c.contract.ID, d.ID), func() {
+		for i := range c.commonConfigs {
+			c.commonConfigs[i].Default = types.Disabled
+			if c.commonConfigs[i].ID == d.ID {
+				c.commonConfigs[i].Default = types.Enabled
+			}
+		}
+	})
+}
+
+func (f *FakeServer) readCommonConfig(w http.ResponseWriter, r *http.Request, c *fakeContract, i int) {
+	f.writeResult(w, &c.commonConfigs[i])
+}
+
+func (f *FakeServer) updateCommonConfig(w http.ResponseWriter, r *http.Request, c *fakeContract, i int) {
+	cc := c.commonConfigs[i]
+	if err := decodeBody(r, api.ActionUpdate, &cc); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	if cc.Name == "" {
+		f.writeInvalid(w, "Name is required.", "name")
+		return
+	}
+	f.writeAsync(w, r, fmt.Sprintf("/contracts/%s/common_configs/%d", c.contract.ID, cc.ID), func() {
+		if i := c.commonConfigIndex(cc.ID); i >= 0 {
+			c.commonConfigs[i] = cc
+		}
+	})
+}
+
+func (f *FakeServer) deleteCommonConfig(w http.ResponseWriter, r *http.Request, c *fakeContract, i int) {
+	if c.commonConfigs[i].Default == types.Enabled {
+		f.writeInvalid(w, "Default common config can not be deleted.", "id")
+		return
+	}
+	id := c.commonConfigs[i].ID
+	f.writeAsync(w, r, "", func() {
+		if i := c.commonConfigIndex(id); i >= 0 {
+			c.commonConfigs = append(c.commonConfigs[:i], c.commonConfigs[i+1:]...)
+		}
+	})
+}
+
+func (f *FakeServer) lbDomainRoutes() {
+	f.handle(http.MethodGet, `/lb_domains(/count)?`, f.listLBDomains)
+	f.handle(http.MethodGet, `/lb_domains/([^/]+)`, f.withLBDomain(f.readLBDomain))
+	f.handle(http.MethodPatch, `/lb_domains/([^/]+)`, f.withLBDomain(f.updateLBDomain))
+	f.handle(http.MethodGet, `/lb_domains/([^/]+)/config`, f.withLBDomain(f.readLBDomainConfig))
+	f.handle(http.MethodPut, `/lb_domains/([^/]+)/config`, f.withLBDomain(f.applyLBDomainConfig))
+}
+
+func (f *FakeServer) withLBDomain(h func(w http.ResponseWriter, r *http.Request, l *fakeLBDomain)) func(w http.ResponseWriter, r *http.Request, args []string) {
+	return func(w http.ResponseWriter, r *http.Request, args []string) {
+		l, ok := f.lbDomains[args[0]]
+		if !ok {
+			f.writeNotFound(w)
+			return
+		}
+		h(w, r, l)
+	}
+}
+
+func (f *FakeServer) listLBDomains(w http.ResponseWriter, r *http.Request, args []string) {
+	items := []core.LBDomain{}
+	for _, id := range f.lbIDs {
+		items = append(items, f.lbDomains[id].lbDomain)
+	}
+	f.writeList(w, r, items, args[0] != "")
+}
+
+func (f *FakeServer) readLBDomain(w http.ResponseWriter, r *http.Request, l *fakeLBDomain) {
+	f.writeResult(w, &l.lbDomain)
+}
+
+func (f *FakeServer) updateLBDomain(w http.ResponseWriter, r *http.Request, l *fakeLBDomain) {
+	lbDomain := l.lbDomain
+	if err := decodeBody(r, api.ActionUpdate, &lbDomain); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	f.writeAsync(w, r, "/lb_domains/"+lbDomain.ID, func() { l.lbDomain = lbDomain })
+}
+
+func (f *FakeServer) readLBDomainConfig(w http.ResponseWriter, r *http.Request, l *fakeLBDomain) {
+	f.writeResult(w, l.config)
+}
+
+func (f *FakeServer) applyLBDomainConfig(w http.ResponseWriter, r *http.Request, l *fakeLBDomain) {
+	bs, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	// apply body has same attributes as read response
+	if err := api.UnmarshalRead(bs, &lb_domains.Config{}); err != nil {
+		f.writeInvalid(w, fmt.Sprintf("Invalid config: %s", err), "body")
+		return
+	}
+	f.writeAsync(w, r, fmt.Sprintf("/lb_domains/%s/config", l.lbDomain.ID), func() { l.config = json.RawMessage(bs) })
+}
@@ -0,0 +1,342 @@
+package testtool_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/time/rate"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/contracts"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/lb_domains"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+var _ = Describe("FakeServer", func() {
+	var (
+		fs  *testtool.FakeServer
+		srv *httptest.Server
+		cl  *api.Client
+		ctx context.Context
+		err error
+		job *core.Job
+	)
+	listRecords := func() []zones.Record {
+		list := &zones.RecordList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+		_, err := cl.ListAll(ctx, list, nil)
+		ExpectWithOffset(1, err).To(Succeed())
+		return list.Items
+	}
+	BeforeEach(func() {
+		ctx = context.Background()
+		fs = testtool.NewFakeServer()
+		fs.Token = "token"
+		fs.AddZone(core.Zone{ID: "m1", Name: "example.jp.", ServiceCode: "dpm0000001"},
+			zones.Record{ID: "r1", Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}}},
+		)
+		fs.AddZone(core.Zone{ID: "m2", Name: "example.net.", ServiceCode: "dpm0000002"})
+		srv = httptest.NewServer(fs)
+		// httpmock replaces http.DefaultTransport in this suite
+		cl = api.NewClient("token", srv.URL, nil)
+		cl.SetRoundTripper(api.NewRateRoundTripper(&http.Transport{}, rate.NewLimiter(rate.Inf, 0)))
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	Context("auth", func() {
+		It("returns auth error for invalid token", func() {
+			cl.Token = "invalid"
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(api.IsAuthError(err)).To(BeTrue())
+		})
+	})
+	Context("zones", func() {
+		It("lists zones", func() {
+			list := &core.ZoneList{}
+			_, err = cl.ListAll(ctx, list, nil)
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(HaveLen(2))
+		})
+		It("searches zones by keywords", func() {
+			id, err := apiutils.GetZoneIDFromZonename(ctx, cl, "example.net.")
+			Expect(err).To(Succeed())
+			Expect(id).To(Equal("m2"))
+		})
+		It("returns not found", func() {
+			_, err = cl.Read(ctx, &core.Zone{ID: "m3"})
+			Expect(api.IsNotFound(err)).To(BeTrue())
+		})
+		It("updates zone", func() {
+			_, _, err = apiutils.SyncUpdate(ctx, cl, &core.Zone{ID: "m1", Description: "updated"}, nil)
+			Expect(err).To(Succeed())
+			zone := &core.Zone{ID: "m1"}
+			_, err = cl.Read(ctx, zone)
+			Expect(err).To(Succeed())
+			Expect(zone.Description).To(Equal("updated"))
+			Expect(zone.Name).To(Equal("example.jp."))
+		})
+	})
+	Context("records", func() {
+		It("supports create, apply, update, cancel and delete workflow", func() {
+			By("create")
+			_, job, err = apiutils.SyncCreate(ctx, cl, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "mail.example.jp.",
+				RRType:        zones.TypeMX,
+				RData:         zones.RecordRDATASlice{{Value: "10 mx.example.jp."}},
+			}, nil)
+			Expect(err).To(Succeed())
+			id, err := apiutils.ParseeResourceSystemID(job)
+			Expect(err).To(Succeed())
+			record := &zones.Record{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, ID: id}
+			_, err = cl.Read(ctx, record)
+			Expect(err).To(Succeed())
+			Expect(record.State).To(Equal(zones.RecordStateToBeAdded))
+			diffs := &zones.RecordDiffList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			_, err = cl.ListAll(ctx, diffs, nil)
+			Expect(err).To(Succeed())
+			Expect(diffs.Items).To(HaveLen(1))
+			Expect(diffs.Items[0].Old).To(BeNil())
+
+			By("apply")
+			_, _, err = apiutils.SyncApply(ctx, cl, &zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, Description: "add mx"}, nil)
+			Expect(err).To(Succeed())
+			currents := &zones.CurrentRecordList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			_, err = cl.ListAll(ctx, currents, nil)
+			Expect(err).To(Succeed())
+			Expect(currents.Items).To(HaveLen(2))
+			histories := &zones.HistoryList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			_, err = cl.ListAll(ctx, histories, nil)
+			Expect(err).To(Succeed())
+			Expect(histories.Items).To(HaveLen(1))
+			Expect(histories.Items[0].Description).To(Equal("add mx"))
+
+			By("update")
+			record.TTL = 600
+			_, _, err = apiutils.SyncUpdate(ctx, cl, record, nil)
+			Expect(err).To(Succeed())
+			records := listRecords()
+			Expect(records).To(HaveLen(3))
+			Expect(records[1].State).To(Equal(zones.RecordStateToBeUpdate))
+			Expect(records[1].TTL).To(BeEquivalentTo(600))
+			Expect(records[2].State).To(Equal(zones.RecordStateBeforeUpdate))
+			Expect(records[2].TTL).To(BeEquivalentTo(0))
+
+			By("cancel record")
+			_, _, err = apiutils.SyncCancel(ctx, cl, record)
+			Expect(err).To(Succeed())
+			records = listRecords()
+			Expect(records).To(HaveLen(2))
+			Expect(records[1].State).To(Equal(zones.RecordStateApplied))
+
+			By("delete and cancel zone changes")
+			_, _, err = apiutils.SyncDelete(ctx, cl, record)
+			Expect(err).To(Succeed())
+			Expect(listRecords()[1].State).To(Equal(zones.RecordStateToBeDeleted))
+			_, _, err = apiutils.SyncCancel(ctx, cl, &zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}})
+			Expect(err).To(Succeed())
+			Expect(listRecords()[1].State).To(Equal(zones.RecordStateApplied))
+
+			By("delete and apply")
+			_, _, err = apiutils.SyncDelete(ctx, cl, record)
+			Expect(err).To(Succeed())
+			_, _, err = apiutils.SyncApply(ctx, cl, &zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}, nil)
+			Expect(err).To(Succeed())
+			Expect(listRecords()).To(HaveLen(1))
+		})
+		It("finds record by name and type", func() {
+			record, err := apiutils.GetRecordFromZoneID(ctx, cl, "m1", "www.example.jp.", zones.TypeA)
+			Expect(err).To(Succeed())
+			Expect(record.ID).To(Equal("r1"))
+		})
+		It("paginates records", func() {
+			for _, name := range []string{"a", "b", "c", "d"} {
+				_, err = cl.Create(ctx, &zones.Record{
+					AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+					Name:          name + ".example.jp.",
+					RRType:        zones.TypeTXT,
+					RData:         zones.RecordRDATASlice{{Value: name}},
+				}, nil)
+				Expect(err).To(Succeed())
+			}
+			list := &zones.RecordList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			p := api.Paginate(ctx, cl, list, &api.CommonSearchParams{Limit: 2})
+			pages := 0
+			for p.Next() {
+				pages++
+			}
+			Expect(p.Err()).To(Succeed())
+			Expect(pages).To(Equal(3))
+			Expect(p.Fetched()).To(BeEquivalentTo(5))
+		})
+		It("returns ParameterError for invalid record", func() {
+			_, err = cl.Create(ctx, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "www.example.jp.",
+				RRType:        zones.TypeAAAA,
+				RData:         zones.RecordRDATASlice{{Value: "192.168.0.1"}},
+			}, nil)
+			Expect(api.IsParameterError(err)).To(BeTrue())
+			Expect(api.IsErrorCodeAttribute(err, "invalid", "rdata[0].value")).To(BeTrue())
+		})
+		It("returns ParameterError for existing RRset", func() {
+			_, err = cl.Create(ctx, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "www.example.jp.",
+				RRType:        zones.TypeA,
+				RData:         zones.RecordRDATASlice{{Value: "192.168.0.2"}},
+			}, nil)
+			Expect(api.IsParameterError(err)).To(BeTrue())
+		})
+		It("returns ParameterError for out of zone record", func() {
+			_, err = cl.Create(ctx, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "www.example.net.",
+				RRType:        zones.TypeA,
+				RData:         zones.RecordRDATASlice{{Value: "192.168.0.2"}},
+			}, nil)
+			Expect(api.IsErrorCodeAttribute(err, "invalid", "name")).To(BeTrue())
+		})
+		It("returns ParameterError for applying no changes", func() {
+			_, err = cl.Apply(ctx, &zones.ZoneApply{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}, nil)
+			Expect(api.IsParameterError(err)).To(BeTrue())
+		})
+	})
+	Context("default ttl", func() {
+		It("updates and cancels default ttl", func() {
+			_, _, err = apiutils.SyncUpdate(ctx, cl, &zones.DefaultTTL{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}, Value: 300}, nil)
+			Expect(err).To(Succeed())
+			ttl := &zones.DefaultTTL{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			_, err = cl.Read(ctx, ttl)
+			Expect(err).To(Succeed())
+			Expect(ttl.Value).To(BeEquivalentTo(300))
+			Expect(ttl.State).To(Equal(zones.DefaultTTLStateToBeUpdate))
+			diffs := &zones.DefaultTTLDiffList{AttributeMeta: zones.AttributeMeta{ZoneID: "m1"}}
+			_, err = cl.List(ctx, diffs, nil)
+			Expect(err).To(Succeed())
+			Expect(diffs.Items).To(HaveLen(1))
+			Expect(diffs.Items[0].Old.Value).To(BeEquivalentTo(3600))
+
+			_, _, err = apiutils.SyncCancel(ctx, cl, ttl)
+			Expect(err).To(Succeed())
+			_, err = cl.Read(ctx, ttl)
+			Expect(err).To(Succeed())
+			Expect(ttl.Value).To(BeEquivalentTo(3600))
+			Expect(ttl.State).To(Equal(zones.DefaultTTLStateApplied))
+		})
+	})
+	Context("jobs", func() {
+		It("returns RUNNING until JobPolls", func() {
+			fs.JobPolls = 1
+			reqID, err := cl.Update(ctx, &core.Zone{ID: "m1"}, nil)
+			Expect(err).To(Succeed())
+			job = &core.Job{RequestID: reqID}
+			_, err = cl.Read(ctx, job)
+			Expect(err).To(Succeed())
+			Expect(job.Status).To(Equal(core.JobStatusRunning))
+			_, err = cl.Read(ctx, job)
+			Expect(err).To(Succeed())
+			Expect(job.Status).To(Equal(core.JobStatusSuccessful))
+			Expect(job.ResourceUrl).To(Equal(srv.URL + "/zones/m1"))
+		})
+		It("changes state when the job becomes SUCCESSFUL", func() {
+			fs.JobPolls = 1
+			reqID, err := cl.Create(ctx, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "mail.example.jp.",
+				TTL:           300,
+				RRType:        zones.TypeMX,
+				RData:         zones.RecordRDATASlice{{Value: "10 mx.example.jp."}},
+			}, nil)
+			Expect(err).To(Succeed())
+			Expect(listRecords()).To(HaveLen(1))
+
+			job = &core.Job{RequestID: reqID}
+			_, err = cl.Read(ctx, job)
+			Expect(err).To(Succeed())
+			Expect(job.Status).To(Equal(core.JobStatusRunning))
+			Expect(listRecords()).To(HaveLen(1))
+
+			_, err = cl.Read(ctx, job)
+			Expect(err).To(Succeed())
+			Expect(job.Status).To(Equal(core.JobStatusSuccessful))
+			records := listRecords()
+			Expect(records).To(HaveLen(2))
+			Expect(records[1].State).To(Equal(zones.RecordStateToBeAdded))
+		})
+		It("fails next job", func() {
+			fs.FailNextJob("ParameterError", "failed")
+			_, job, err = apiutils.SyncCreate(ctx, cl, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "mail.example.jp.",
+				RRType:        zones.TypeMX,
+				RData:         zones.RecordRDATASlice{{Value: "10 mx.example.jp."}},
+			}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(job.Status).To(Equal(core.JobStatusFailed))
+			Expect(listRecords()).To(HaveLen(1))
+		})
+		It("returns injected error", func() {
+			fs.InjectError(http.MethodGet, "/zones/m1", &api.BadResponse{
+				StatusCode:   http.StatusTooManyRequests,
+				ErrorType:    api.ErrorTypeTooManyRequests,
+				ErrorMessage: "Too many requests.",
+			})
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(api.IsTooManyRequests(err)).To(BeTrue())
+			_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			Expect(err).To(Succeed())
+		})
+	})
+	Context("contracts", func() {
+		BeforeEach(func() {
+			fs.AddContract(core.Contract{ID: "f1", ServiceCode: "dpf0000001", Plan: core.PlanBasic},
+				contracts.CommonConfig{ID: 1, Name: "default"},
+			)
+		})
+		It("manages common configs", func() {
+			_, job, err = apiutils.SyncCreate(ctx, cl, &contracts.CommonConfig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, Name: "new"}, nil)
+			Expect(err).To(Succeed())
+			id, err := apiutils.ParseeResourceID(job)
+			Expect(err).To(Succeed())
+
+			_, _, err = apiutils.SyncApply(ctx, cl, &contracts.CommonConfigDefault{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, ID: id}, nil)
+			Expect(err).To(Succeed())
+			list := &contracts.CommonConfigList{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}}
+			_, err = cl.ListAll(ctx, list, nil)
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(HaveLen(2))
+			Expect(list.Items[0].Default).To(Equal(types.Disabled))
+			Expect(list.Items[1].Default).To(Equal(types.Enabled))
+
+			_, err = cl.Delete(ctx, &contracts.CommonConfig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, ID: id})
+			Expect(api.IsParameterError(err)).To(BeTrue())
+			_, _, err = apiutils.SyncDelete(ctx, cl, &contracts.CommonConfig{AttributeMeta: contracts.AttributeMeta{ContractID: "f1"}, ID: 1})
+			Expect(err).To(Succeed())
+		})
+	})
+	Context("lb_domains", func() {
+		BeforeEach(func() {
+			fs.AddLBDomain(core.LBDomain{ID: "b1", Name: "lb.example.jp."})
+		})
+		It("applies config", func() {
+			_, _, err = apiutils.SyncApply(ctx, cl, &lb_domains.Config{
+				AttributeMeta: lb_domains.AttributeMeta{LBDomainID: "b1"},
+				Sites:         []lb_domains.Site{{ResourceName: "s1", Name: "site1", RRType: lb_domains.SiteRRTypeA}},
+			}, nil)
+			Expect(err).To(Succeed())
+			config := &lb_domains.Config{AttributeMeta: lb_domains.AttributeMeta{LBDomainID: "b1"}}
+			_, err = cl.Read(ctx, config)
+			Expect(err).To(Succeed())
+			Expect(config.Sites).To(HaveLen(1))
+			Expect(config.Sites[0].Name).To(Equal("site1"))
+		})
+	})
+})
@@ -0,0 +1,415 @@
+package testtool
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+const fakeDefaultTTL int64 = 3600
+
+type fakeZone struct {
+	zone       core.Zone
+	records    map[string]*fakeRecord
+	recordIDs  []string
+	defaultTTL int64
+	// nil if default ttl is not changed
+	pendingTTL *int64
+	histories  []zones.History
+}
+
+type fakeRecord struct {
+	// applied record, nil if the record is to be added
+	current *zones.Record
+	// changed record, nil if the record is not changed
+	pending *zones.Record
+	deleted bool
+}
+
+// AddZone adds zone and its applied records to the server.
+// If ID of record is empty, it is generated.
+func (f *FakeServer) AddZone(zone core.Zone, records ...zones.Record) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	z := &fakeZone{
+		zone:       zone,
+		records:    map[string]*fakeRecord{},
+		defaultTTL: fakeDefaultTTL,
+	}
+	for _, record := range records {
+		record := record
+		if record.ID == "" {
+			record.ID = f.nextRecordID(z)
+		}
+		record.State = zones.RecordStateApplied
+		z.addRecord(&fakeRecord{current: &record})
+	}
+	if _, ok := f.zones[zone.ID]; !ok {
+		f.zoneIDs = append(f.zoneIDs, zone.ID)
+	}
+	f.zones[zone.ID] = z
+}
+
+func (f *FakeServer) nextRecordID(z *fakeZone) string {
+	for {
+		id := fmt.Sprintf("r%d", f.nextID())
+		if _, ok := z.records[id]; !ok {
+			return id
+		}
+	}
+}
+
+func (z *fakeZone) addRecord(r *fakeRecord) {
+	var id string
+	if r.current != nil {
+		id = r.current.ID
+	} else {
+		id = r.pending.ID
+	}
+	z.records[id] = r
+	z.recordIDs = append(z.recordIDs, id)
+}
+
+func (z *fakeZone) removeRecord(id string) {
+	delete(z.records, id)
+	for i, rid := range z.recordIDs {
+		if rid == id {
+			z.recordIDs = append(z.recordIDs[:i], z.recordIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+// view returns records shown in records API.
+func (r *fakeRecord) view() []zones.Record {
+	switch {
+	case r.deleted:
+		return []zones.Record{withState(r.current, zones.RecordStateToBeDeleted)}
+	case r.pending == nil:
+		return []zones.Record{withState(r.current, zones.RecordStateApplied)}
+	case r.current == nil:
+		return []zones.Record{withState(r.pending, zones.RecordStateToBeAdded)}
+	}
+	return []zones.Record{
+		withState(r.pending, zones.RecordStateToBeUpdate),
+		withState(r.current, zones.RecordStateBeforeUpdate),
+	}
+}
+
+func withState(r *zones.Record, state zones.RecordState) zones.Record {
+	res := *r.DeepCopy()
+	res.State = state
+	return res
+}
+
+func (z *fakeZone) changed() bool {
+	if z.pendingTTL != nil {
+		return true
+	}
+	for _, r := range z.records {
+		if r.deleted || r.pending != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeServer) zoneRoutes() {
+	f.handle(http.MethodGet, `/zones(/count)?`, f.listZones)
+	f.handle(http.MethodGet, `/zones/([^/]+)`, f.withZone(f.readZone))
+	f.handle(http.MethodPatch, `/zones/([^/]+)`, f.withZone(f.updateZone))
+	f.handle(http.MethodPatch, `/zones/([^/]+)/changes`, f.withZone(f.applyZone))
+	f.handle(http.MethodDelete, `/zones/([^/]+)/changes`, f.withZone(f.cancelZone))
+	f.handle(http.MethodGet, `/zones/([^/]+)/records(/count)?`, f.withZone(f.listRecords))
+	f.handle(http.MethodGet, `/zones/([^/]+)/records/currents(/count)?`, f.withZone(f.listCurrentRecords))
+	f.handle(http.MethodGet, `/zones/([^/]+)/records/diffs(/count)?`, f.withZone(f.listRecordDiffs))
+	f.handle(http.MethodPost, `/zones/([^/]+)/records`, f.withZone(f.createRecord))
+	f.handle(http.MethodGet, `/zones/([^/]+)/records/([^/]+)`, f.withZone(f.withRecord(f.readRecord)))
+	f.handle(http.MethodPatch, `/zones/([^/]+)/records/([^/]+)`, f.withZone(f.withRecord(f.updateRecord)))
+	f.handle(http.MethodDelete, `/zones/([^/]+)/records/([^/]+)`, f.withZone(f.withRecord(f.deleteRecord)))
+	f.handle(http.MethodDelete, `/zones/([^/]+)/records/([^/]+)/changes`, f.withZone(f.withRecord(f.cancelRecord)))
+	f.handle(http.MethodGet, `/zones/([^/]+)/default_ttl`, f.withZone(f.readDefaultTTL))
+	f.handle(http.MethodPatch, `/zones/([^/]+)/default_ttl`, f.withZone(f.updateDefaultTTL))
+	f.handle(http.MethodDelete, `/zones/([^/]+)/default_ttl/changes`, f.withZone(f.cancelDefaultTTL))
+	f.handle(http.MethodGet, `/zones/([^/]+)/default_ttl/diffs`, f.withZone(f.listDefaultTTLDiffs))
+	f.handle(http.MethodGet, `/zones/([^/]+)/zone_histories(/count)?`, f.withZone(f.listHistories))
+}
+
+func (f *FakeServer) withZone(h func(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string)) func(w http.ResponseWriter, r *http.Request, args []string) {
+	return func(w http.ResponseWriter, r *http.Request, args []string) {
+		z, ok := f.zones[args[0]]
+		if !ok {
+			f.writeNotFound(w)
+			return
+		}
+		h(w, r, z, args[1:])
+	}
+}
+
+func (f *FakeServer) withRecord(h func(w http.ResponseWriter, r *http.Request, z *fakeZone, rec *fakeRecord)) func(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string) {
+	return func(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string) {
+		rec, ok := z.records[args[0]]
+		if !ok {
+			f.writeNotFound(w)
+			return
+		}
+		h(w, r, z, rec)
+	}
+}
+
+func (f *FakeServer) listZones(w http.ResponseWriter, r *http.Request, args []string) {
+	items := []core.Zone{}
+	for _, id := range f.zoneIDs {
+		items = append(items, f.zones[id].zone)
+	}
+	f.writeList(w, r, items, args[0] != "")
+}
+
+func (f *FakeServer) readZone(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	f.writeResult(w, &z.zone)
+}
+
+func (f *FakeServer) updateZone(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	zone := z.zone
+	if err := decodeBody(r, api.ActionUpdate, &zone); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	f.writeAsync(w, r, "/zones/"+zone.ID, func() { z.zone = zone })
+}
+
+func (f *FakeServer) applyZone(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	apply := &zones.ZoneApply{}
+	if err := decodeBody(r, api.ActionApply, apply); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	if !z.changed() {
+		f.writeInvalid(w, "There are no changes.", "changes")
+		return
+	}
+	f.writeAsync(w, r, "/zones/"+z.zone.ID, func() {
+		for _, id := range append([]string{}, z.recordIDs...) {
+			rec := z.records[id]
+			switch {
+			case rec.deleted:
+				z.removeRecord(id)
+			case rec.pending != nil:
+				rec.current, rec.pending = rec.pending, nil
+			}
+		}
+		if z.pendingTTL != nil {
+			z.defaultTTL, z.pendingTTL = *z.pendingTTL, nil
+		}
+		z.histories = append([]zones.History{{
+			ID:          f.nextID(),
+			CommittedAt: types.Time{Time: time.Now().UTC()},
+			Description: apply.Description,
+		}}, z.histories...)
+	})
+}
+
+func (f *FakeServer) cancelZone(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	f.writeAsync(w, r, "/zones/"+z.zone.ID, func() {
+		for _, id := range append([]string{}, z.recordIDs...) {
+			z.cancelRecord(id)
+		}
+		z.pendingTTL = nil
+	})
+}
+
+// cancelRecord cancels changes of the record, it does nothing if the record doesn't exist.
+func (z *fakeZone) cancelRecord(id string) {
+	rec, ok := z.records[id]
+	if !ok {
+		return
+	}
+	if rec.current == nil {
+		z.removeRecord(id)
+		return
+	}
+	rec.pending = nil
+	rec.deleted = false
+}
+
+func (f *FakeServer) listRecords(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string) {
+	items := []zones.Record{}
+	for _, id := range z.recordIDs {
+		items = append(items, z.records[id].view()...)
+	}
+	f.writeList(w, r, items, args[0] != "")
+}
+
+func (f *FakeServer) listCurrentRecords(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string) {
+	items := []zones.Record{}
+	for _, id := range z.recordIDs {
+		if rec := z.records[id]; rec.current != nil {
+			items = append(items, withState(rec.current, zones.RecordStateApplied))
+		}
+	}
+	f.writeList(w, r, items, args[0] != "")
+}
+
+func (f *FakeServer) listRecordDiffs(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string) {
+	items := []zones.RecordDiff{}
+	for _, id := range z.recordIDs {
+		rec := z.records[id]
+		switch {
+		case rec.deleted:
+			items = append(items, zones.RecordDiff{Old: rec.current})
+		case rec.pending != nil:
+			items = append(items, zones.RecordDiff{New: rec.pending, Old: rec.current})
+		}
+	}
+	f.writeList(w, r, items, args[0] != "")
+}
+
+// validateRecord returns false and writes error response, if record is invalid.
+func (f *FakeServer) validateRecord(w http.ResponseWriter, z *fakeZone, record *zones.Record) bool {
+	if err := record.Validate(); err != nil {
+		attrs := []string{}
+		if errs, ok := err.(zones.FieldErrors); ok {
+			for _, fe := range errs {
+				attrs = append(attrs, fe.Field)
+			}
+		}
+		f.writeInvalid(w, err.Error(), attrs...)
+		return false
+	}
+	if !dns.IsSubDomain(z.zone.Name, record.Name) {
+		f.writeInvalid(w, fmt.Sprintf("%s is out of zone.", record.Name), "name")
+		return false
+	}
+	for _, id := range z.recordIDs {
+		rec := z.records[id]
+		exist := rec.pending
+		if exist == nil {
+			exist = rec.current
+		}
+		if id == record.ID || rec.deleted {
+			continue
+		}
+		if exist.RRType == record.RRType && dns.CanonicalName(exist.Name) == dns.CanonicalName(record.Name) {
+			f.writeInvalid(w, fmt.Sprintf("%s %s already exists.", record.Name, record.RRType), "name")
+			return false
+		}
+	}
+	return true
+}
+
+func (f *FakeServer) createRecord(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	record := &zones.Record{}
+	if err := decodeBody(r, api.ActionCreate, record); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	record.AttributeMeta = zones.AttributeMeta{ZoneID: z.zone.ID}
+	if !f.validateRecord(w, z, record) {
+		return
+	}
+	record.ID = f.nextRecordID(z)
+	f.writeAsync(w, r, fmt.Sprintf("/zones/%s/records/%s", z.zone.ID, record.ID), func() {
+		z.addRecord(&fakeRecord{pending: record})
+	})
+}
+
+func (f *FakeServer) readRecord(w http.ResponseWriter, r *http.Request, z *fakeZone, rec *fakeRecord) {
+	record := rec.view()[0]
+	f.writeResult(w, &record)
+}
+
+func (f *FakeServer) updateRecord(w http.ResponseWriter, r *http.Request, z *fakeZone, rec *fakeRecord) {
+	if rec.deleted {
+		f.writeInvalid(w, "Record is to be deleted.", "id")
+		return
+	}
+	exist := rec.current
+	if exist == nil {
+		exist = rec.pending
+	}
+	record := &zones.Record{}
+	if err := decodeBody(r, api.ActionUpdate, record); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	record.AttributeMeta = exist.AttributeMeta
+	record.ID = exist.ID
+	record.Name = exist.Name
+	record.RRType = exist.RRType
+	if !f.validateRecord(w, z, record) {
+		return
+	}
+	f.writeAsync(w, r, fmt.Sprintf("/zones/%s/records/%s", z.zone.ID, record.ID), func() { rec.pending = record })
+}
+
+func (f *FakeServer) deleteRecord(w http.ResponseWriter, r *http.Request, z *fakeZone, rec *fakeRecord) {
+	id := rec.view()[0].ID
+	f.writeAsync(w, r, "", func() {
+		if rec.current == nil {
+			z.removeRecord(id)
+			return
+		}
+		rec.pending = nil
+		rec.deleted = true
+	})
+}
+
+func (f *FakeServer) cancelRecord(w http.ResponseWriter, r *http.Request, z *fakeZone, rec *fakeRecord) {
+	id := rec.view()[0].ID
+	f.writeAsync(w, r, fmt.Sprintf("/zones/%s/records/%s", z.zone.ID, id), func() { z.cancelRecord(id) })
+}
+
+func (z *fakeZone) defaultTTLView() *zones.DefaultTTL {
+	res := &zones.DefaultTTL{
+		AttributeMeta: zones.AttributeMeta{ZoneID: z.zone.ID},
+		Value:         z.defaultTTL,
+		State:         zones.DefaultTTLStateApplied,
+	}
+	if z.pendingTTL != nil {
+		res.Value = *z.pendingTTL
+		res.State = zones.DefaultTTLStateToBeUpdate
+	}
+	return res
+}
+
+func (f *FakeServer) readDefaultTTL(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	f.writeResult(w, z.defaultTTLView())
+}
+
+func (f *FakeServer) updateDefaultTTL(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	ttl := &zones.DefaultTTL{}
+	if err := decodeBody(r, api.ActionUpdate, ttl); err != nil {
+		f.writeInvalid(w, "Invalid request body.", "body")
+		return
+	}
+	if ttl.Value < int64(zones.RecordMinTTL) || ttl.Value > int64(zones.RecordMaxTTL) {
+		f.writeInvalid(w, "Invalid default ttl.", "value")
+		return
+	}
+	f.writeAsync(w, r, fmt.Sprintf("/zones/%s/default_ttl", z.zone.ID), func() { z.pendingTTL = &ttl.Value })
+}
+
+func (f *FakeServer) cancelDefaultTTL(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	f.writeAsync(w, r, fmt.Sprintf("/zones/%s/default_ttl", z.zone.ID), func() { z.pendingTTL = nil })
+}
+
+func (f *FakeServer) listDefaultTTLDiffs(w http.ResponseWriter, r *http.Request, z *fakeZone, _ []string) {
+	items := []zones.DefaultTTLDiff{}
+	if z.pendingTTL != nil {
+		items = append(items, zones.DefaultTTLDiff{
+			New: z.defaultTTLView(),
+			Old: &zones.DefaultTTL{Value: z.defaultTTL, State: zones.DefaultTTLStateBeforeUpdate},
+		})
+	}
+	f.writeList(w, r, items, false)
+}
+
+func (f *FakeServer) listHistories(w http.ResponseWriter, r *http.Request, z *fakeZone, args []string) {
+	f.writeList(w, r, z.histories, args[0] != "")
+}
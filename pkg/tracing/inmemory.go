@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Tracer = &InMemoryTracer{}
+
+// InMemoryTracer records spans in memory, it is exporter for testing.
+type InMemoryTracer struct {
+	mu    sync.Mutex
+	seq   uint64
+	spans []*SpanData
+}
+
+// SpanData is recorded span.
+// ParentID is 0, if the span is root.
+type SpanData struct {
+	Name       string
+	TraceID    uint64
+	SpanID     uint64
+	ParentID   uint64
+	Attributes map[string]interface{}
+	Errors     []error
+	StartTime  time.Time
+	EndTime    time.Time
+	Ended      bool
+}
+
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+type inMemorySpanKey struct{}
+
+func (t *InMemoryTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	data := &SpanData{
+		Name:       name,
+		TraceID:    t.seq,
+		SpanID:     t.seq,
+		Attributes: map[string]interface{}{},
+		StartTime:  time.Now(),
+	}
+	if parent, ok := ctx.Value(inMemorySpanKey{}).(*inMemorySpan); ok && parent.tracer == t {
+		data.TraceID = parent.data.TraceID
+		data.ParentID = parent.data.SpanID
+	}
+	for _, attr := range attrs {
+		data.Attributes[attr.Key] = attr.Value
+	}
+	t.spans = append(t.spans, data)
+	span := &inMemorySpan{tracer: t, data: data}
+	return context.WithValue(ctx, inMemorySpanKey{}, span), span
+}
+
+// Spans returns copy of recorded spans in started order.
+func (t *InMemoryTracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]SpanData, 0, len(t.spans))
+	for _, data := range t.spans {
+		d := *data
+		d.Attributes = map[string]interface{}{}
+		for key, value := range data.Attributes {
+			d.Attributes[key] = value
+		}
+		d.Errors = append([]error{}, data.Errors...)
+		res = append(res, d)
+	}
+	return res
+}
+
+// Reset removes recorded spans.
+func (t *InMemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+type inMemorySpan struct {
+	tracer *InMemoryTracer
+	data   *SpanData
+}
+
+func (s *inMemorySpan) SetAttributes(attrs ...Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.data.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *inMemorySpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.data.Errors = append(s.data.Errors, err)
+}
+
+func (s *inMemorySpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if !s.data.Ended {
+		s.data.Ended = true
+		s.data.EndTime = time.Now()
+	}
+}
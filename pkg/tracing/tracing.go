@@ -0,0 +1,148 @@
+// Package tracing provides tracing hooks of api.Client and apiutils.
+// The interfaces are small enough to be implemented by OpenTelemetry adapter.
+//
+//	t := tracing.NewInMemoryTracer()
+//	cl := api.New(token, api.WithMiddleware(tracing.Middleware(t)))
+//	ctx = tracing.ContextWithTracer(ctx, t) // spans of apiutils.WaitJob and Sync*
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+)
+
+// Attribute keys of spans.
+const (
+	AttributeAction     = "dpf.action"
+	AttributeSpecGroup  = "dpf.spec.group"
+	AttributeSpecName   = "dpf.spec.name"
+	AttributeRequestID  = "dpf.request_id"
+	AttributeJobID      = "dpf.job.id"
+	AttributeJobStatus  = "dpf.job.status"
+	AttributeErrorType  = "dpf.error_type"
+	AttributeHTTPMethod = "http.method"
+	AttributeURLPath    = "url.path"
+	AttributeHTTPStatus = "http.status_code"
+)
+
+const spanNamePrefix = "dpf."
+
+// Attribute is key/value pair of span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts span.
+// The returned ctx has the span, spans started by the ctx are its children.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a unit of work.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+var _ Tracer = NopTracer{}
+
+// NopTracer does nothing.
+type NopTracer struct{}
+
+func (NopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute) {}
+func (nopSpan) RecordError(error)          {}
+func (nopSpan) End()                       {}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns ctx which has tracer.
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// TracerFromContext returns tracer of ctx.
+// If ctx doesn't have tracer, NopTracer is returned.
+func TracerFromContext(ctx context.Context) Tracer {
+	if tracer, ok := ctx.Value(tracerKey{}).(Tracer); ok && tracer != nil {
+		return tracer
+	}
+	return NopTracer{}
+}
+
+// Start starts span by tracer of ctx.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return TracerFromContext(ctx).Start(ctx, spanNamePrefix+name, attrs...)
+}
+
+// SpecAttributes returns attributes of spec kind.
+func SpecAttributes(s api.Spec) []Attribute {
+	return []Attribute{
+		Attr(AttributeSpecGroup, s.GetGroup()),
+		Attr(AttributeSpecName, s.GetName()),
+	}
+}
+
+// Middleware returns api.Middleware which starts span of each Client.Do.
+// The span name is `dpf.<Action>`.
+// If tracer is nil, tracer of ctx is used.
+func Middleware(tracer Tracer) api.Middleware {
+	return func(next api.Invoker) api.Invoker {
+		return func(ctx context.Context, call *api.Call) (string, error) {
+			t := tracer
+			if t == nil {
+				t = TracerFromContext(ctx)
+			}
+			method, path := call.Spec.GetPathMethod(call.Action)
+			if i := strings.Index(path, "?"); i >= 0 {
+				path = path[:i]
+			}
+			attrs := append([]Attribute{
+				Attr(AttributeAction, string(call.Action)),
+				Attr(AttributeHTTPMethod, method),
+				Attr(AttributeURLPath, path),
+			}, SpecAttributes(call.Spec)...)
+			ctx, span := t.Start(ctx, spanNamePrefix+string(call.Action), attrs...)
+			defer span.End()
+
+			prev := api.ResponseHookFromContext(ctx)
+			ctx = api.ContextWithResponseHook(ctx, func(ctx context.Context, reqInfo *api.RequestInfo, respInfo *api.ResponseInfo) {
+				if respInfo != nil && respInfo.Response != nil {
+					span.SetAttributes(Attr(AttributeHTTPStatus, respInfo.Response.StatusCode))
+				}
+				if prev != nil {
+					prev(ctx, reqInfo, respInfo)
+				}
+			})
+			requestID, err := next(ctx, call)
+			if requestID != "" {
+				span.SetAttributes(Attr(AttributeRequestID, requestID))
+			}
+			if err != nil {
+				bad := &api.BadResponse{}
+				if errors.As(err, &bad) {
+					span.SetAttributes(
+						Attr(AttributeErrorType, bad.ErrorType),
+						Attr(AttributeHTTPStatus, bad.StatusCode),
+					)
+				}
+				span.RecordError(err)
+			}
+			return requestID, err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	"github.com/mimuret/golang-iij-dpf/pkg/tracing"
+)
+
+var _ = Describe("tracing", func() {
+	var (
+		t   *tracing.InMemoryTracer
+		fs  *testtool.FakeServer
+		srv *httptest.Server
+		cl  *api.Client
+		ctx context.Context
+		err error
+	)
+	BeforeEach(func() {
+		t = tracing.NewInMemoryTracer()
+		fs = testtool.NewFakeServer()
+		fs.AddZone(core.Zone{ID: "m1", Name: "example.jp."})
+		srv = httptest.NewServer(fs)
+		cl = api.New("token", api.WithEndpoint(srv.URL), api.WithMiddleware(tracing.Middleware(t)))
+		ctx = context.Background()
+	})
+	AfterEach(func() {
+		srv.Close()
+	})
+	Context("Middleware", func() {
+		When("request is successful", func() {
+			BeforeEach(func() {
+				_, err = cl.Read(ctx, &core.Zone{ID: "m1"})
+			})
+			It("records span", func() {
+				Expect(err).To(Succeed())
+				spans := t.Spans()
+				Expect(spans).To(HaveLen(1))
+				Expect(spans[0].Name).To(Equal("dpf.Read"))
+				Expect(spans[0].Ended).To(BeTrue())
+				Expect(spans[0].ParentID).To(BeZero())
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeAction, "Read"))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeSpecName, "zones"))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeSpecGroup, "core.api.dns-platform.jp/v1"))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeHTTPMethod, "GET"))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeURLPath, "/zones/m1"))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeHTTPStatus, 200))
+				Expect(spans[0].Attributes).To(HaveKey(tracing.AttributeRequestID))
+			})
+		})
+		When("request is failed", func() {
+			BeforeEach(func() {
+				_, err = cl.Read(ctx, &core.Zone{ID: "m2"})
+			})
+			It("records error", func() {
+				Expect(err).To(HaveOccurred())
+				spans := t.Spans()
+				Expect(spans).To(HaveLen(1))
+				Expect(spans[0].Errors).To(HaveLen(1))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeErrorType, "NotFound"))
+				Expect(spans[0].Attributes).To(HaveKeyWithValue(tracing.AttributeHTTPStatus, 404))
+			})
+		})
+	})
+	Context("apiutils", func() {
+		BeforeEach(func() {
+			ctx = tracing.ContextWithTracer(ctx, t)
+			_, _, err = apiutils.SyncCreate(ctx, cl, &zones.Record{
+				AttributeMeta: zones.AttributeMeta{ZoneID: "m1"},
+				Name:          "www.example.jp.",
+				TTL:           300,
+				RRType:        zones.TypeA,
+				RData:         zones.RecordRDATASlice{{Value: "192.168.0.1"}},
+			}, nil)
+		})
+		It("records job lifecycle as parent spans", func() {
+			Expect(err).To(Succeed())
+			spans := t.Spans()
+			Expect(spans).To(HaveLen(4))
+			sync, create, wait, read := spans[0], spans[1], spans[2], spans[3]
+			Expect(sync.Name).To(Equal("dpf.SyncCreate"))
+			Expect(sync.ParentID).To(BeZero())
+			Expect(sync.Attributes).To(HaveKeyWithValue(tracing.AttributeSpecName, "records"))
+			Expect(create.Name).To(Equal("dpf.Create"))
+			Expect(create.ParentID).To(Equal(sync.SpanID))
+			Expect(wait.Name).To(Equal("dpf.WaitJob"))
+			Expect(wait.ParentID).To(Equal(sync.SpanID))
+			Expect(wait.Attributes).To(HaveKeyWithValue(tracing.AttributeJobID, create.Attributes[tracing.AttributeRequestID]))
+			Expect(wait.Attributes).To(HaveKeyWithValue(tracing.AttributeJobStatus, "SUCCESSFUL"))
+			Expect(read.Name).To(Equal("dpf.Read"))
+			Expect(read.ParentID).To(Equal(wait.SpanID))
+			for _, span := range spans {
+				Expect(span.TraceID).To(Equal(sync.TraceID))
+				Expect(span.Ended).To(BeTrue())
+			}
+		})
+	})
+	Context("NopTracer", func() {
+		It("is used when ctx doesn't have tracer", func() {
+			Expect(tracing.TracerFromContext(context.Background())).To(Equal(tracing.NopTracer{}))
+		})
+	})
+})
@@ -0,0 +1,141 @@
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+)
+
+type ExportOptions struct {
+	// $ORIGIN, it is required when Relative is true.
+	Origin string
+	// $TTL, the value of zones.DefaultTTL. If it is 0, $TTL is not written.
+	DefaultTTL int64
+	// write owner names relative to Origin.
+	Relative bool
+	// write records of all states.
+	// By default, RecordStateToBeDeleted and RecordStateBeforeUpdate records are skipped.
+	AllStates bool
+}
+
+// Export writes records as RFC 1035 master file.
+// Records are sorted by canonical name order and rrtype, rdata are sorted in each RRset.
+// Records whose TTL is null don't have TTL field, so they use $TTL.
+func Export(w io.Writer, records []zones.Record, opt *ExportOptions) error {
+	if opt == nil {
+		opt = &ExportOptions{}
+	}
+	origin := ""
+	if opt.Origin != "" {
+		origin = dns.CanonicalName(opt.Origin)
+	}
+	if opt.Relative && origin == "" {
+		return fmt.Errorf("origin is required for relative names")
+	}
+
+	targets := []zones.Record{}
+	for _, record := range records {
+		if !opt.AllStates && (record.State == zones.RecordStateToBeDeleted || record.State == zones.RecordStateBeforeUpdate) {
+			continue
+		}
+		targets = append(targets, record)
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		if c := compareNames(targets[i].Name, targets[j].Name); c != 0 {
+			return c < 0
+		}
+		return typeOrder(targets[i].RRType) < typeOrder(targets[j].RRType)
+	})
+
+	bw := bufio.NewWriter(w)
+	if origin != "" {
+		fmt.Fprintf(bw, "$ORIGIN %s\n", origin)
+	}
+	if opt.DefaultTTL > 0 {
+		fmt.Fprintf(bw, "$TTL %d\n", opt.DefaultTTL)
+	}
+	for _, record := range targets {
+		lines, err := formatRecord(record, origin, opt.Relative)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if _, err := bw.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func formatRecord(record zones.Record, origin string, relative bool) ([]string, error) {
+	name := dns.CanonicalName(record.Name)
+	owner := name
+	if relative {
+		owner = relativeName(name, origin)
+	}
+	ttl := ""
+	if record.TTL != 0 {
+		ttl = strconv.FormatInt(int64(record.TTL), 10)
+	}
+	rdatas := []string{}
+	for _, v := range record.RData {
+		rdata, err := FormatRDATA(record.RRType, v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format record %s %s `%s`: %w", record.Name, record.RRType, v.Value, err)
+		}
+		rdatas = append(rdatas, rdata)
+	}
+	sort.Strings(rdatas)
+	lines := []string{}
+	for _, rdata := range rdatas {
+		lines = append(lines, strings.Join([]string{owner, ttl, "IN", string(record.RRType), rdata}, "\t"))
+	}
+	return lines, nil
+}
+
+// FormatRDATA returns canonical presentation format of rdata, see zones.FormatRDATA.
+func FormatRDATA(rrtype zones.Type, value string) (string, error) {
+	return zones.FormatRDATA(rrtype, value)
+}
+
+func relativeName(name string, origin string) string {
+	if name == origin {
+		return "@"
+	}
+	if origin == "." {
+		return strings.TrimSuffix(name, ".")
+	}
+	if dns.IsSubDomain(origin, name) {
+		return strings.TrimSuffix(name, "."+origin)
+	}
+	return name
+}
+
+// compareNames compares names by canonical order (RFC 4034 6.1).
+func compareNames(a, b string) int {
+	la := dns.SplitDomainName(dns.CanonicalName(a))
+	lb := dns.SplitDomainName(dns.CanonicalName(b))
+	for i, j := len(la)-1, len(lb)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(la[i], lb[j]); c != 0 {
+			return c
+		}
+	}
+	return len(la) - len(lb)
+}
+
+func typeOrder(t zones.Type) string {
+	switch t {
+	case zones.TypeSOA:
+		return "0"
+	case zones.TypeNS:
+		return "1"
+	}
+	return "2" + string(t)
+}
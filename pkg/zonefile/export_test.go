@@ -0,0 +1,125 @@
+package zonefile_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/zonefile"
+)
+
+var _ = Describe("Export", func() {
+	var (
+		records []zones.Record
+		opt     *zonefile.ExportOptions
+		buf     *bytes.Buffer
+		err     error
+	)
+	BeforeEach(func() {
+		buf = bytes.NewBuffer(nil)
+		opt = &zonefile.ExportOptions{Origin: "example.jp.", DefaultTTL: 300}
+		records = []zones.Record{
+			{Name: "www.example.jp.", TTL: 30, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.2"}, {Value: "192.168.0.1"}}},
+			{Name: "example.jp.", RRType: zones.TypeNS, RData: zones.RecordRDATASlice{{Value: "ns1.example.net."}}},
+			{Name: "txt.example.jp.", RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `v=spf1 "quoted" -all`}, {Value: `"a" "b"`}}},
+			{Name: "example.jp.", RRType: zones.TypeCAA, RData: zones.RecordRDATASlice{{Value: `0 issue "letsencrypt.org"`}}},
+			{Name: "svc.example.jp.", RRType: zones.TypeHTTPS, RData: zones.RecordRDATASlice{{Value: `1 . alpn="h2,h3"`}}},
+			{Name: "alias.example.jp.", RRType: zones.TypeANAME, RData: zones.RecordRDATASlice{{Value: "www.example.jp."}}},
+			{Name: "a.www.example.jp.", RRType: zones.TypeCNAME, RData: zones.RecordRDATASlice{{Value: "www.example.jp."}}},
+			{Name: "deleted.example.jp.", RRType: zones.TypeA, State: zones.RecordStateToBeDeleted, RData: zones.RecordRDATASlice{{Value: "192.168.0.3"}}},
+		}
+	})
+	When("absolute names", func() {
+		BeforeEach(func() {
+			err = zonefile.Export(buf, records, opt)
+		})
+		It("writes master file", func() {
+			Expect(err).To(Succeed())
+			Expect(buf.String()).To(Equal(`$ORIGIN example.jp.
+$TTL 300
+example.jp.		IN	NS	ns1.example.net.
+example.jp.		IN	CAA	0 issue "letsencrypt.org"
+alias.example.jp.		IN	ANAME	www.example.jp.
+svc.example.jp.		IN	HTTPS	1 . alpn="h2,h3"
+txt.example.jp.		IN	TXT	"a" "b"
+txt.example.jp.		IN	TXT	"v=spf1 \"quoted\" -all"
+www.example.jp.	30	IN	A	192.168.0.1
+www.example.jp.	30	IN	A	192.168.0.2
+a.www.example.jp.		IN	CNAME	www.example.jp.
+`))
+		})
+	})
+	When("relative names", func() {
+		BeforeEach(func() {
+			opt.Relative = true
+			err = zonefile.Export(buf, records[:2], opt)
+		})
+		It("writes relative owner names", func() {
+			Expect(err).To(Succeed())
+			Expect(buf.String()).To(Equal(`$ORIGIN example.jp.
+$TTL 300
+@		IN	NS	ns1.example.net.
+www	30	IN	A	192.168.0.1
+www	30	IN	A	192.168.0.2
+`))
+		})
+	})
+	When("relative names without origin", func() {
+		BeforeEach(func() {
+			err = zonefile.Export(buf, records, &zonefile.ExportOptions{Relative: true})
+		})
+		It("returns error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	When("rdata is invalid", func() {
+		BeforeEach(func() {
+			err = zonefile.Export(buf, []zones.Record{{Name: "www.example.jp.", RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "hoge"}}}}, opt)
+		})
+		It("returns error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("failed to format record www.example.jp. A"))
+		})
+	})
+	When("exported text is parsed", func() {
+		var list *zones.RecordList
+		BeforeEach(func() {
+			err = zonefile.Export(buf, records, opt)
+			Expect(err).To(Succeed())
+			list, err = zonefile.Parse(buf, nil)
+		})
+		It("returns same records", func() {
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(HaveLen(7))
+			Expect(list.Items[3]).To(Equal(zones.Record{Name: "svc.example.jp.", TTL: 300, RRType: zones.TypeHTTPS, RData: zones.RecordRDATASlice{{Value: `1 . alpn="h2,h3"`}}}))
+		})
+	})
+	When("TXT is not quoted", func() {
+		var (
+			record zones.Record
+			list   *zones.RecordList
+		)
+		BeforeEach(func() {
+			record = zones.Record{Name: "example.jp.", TTL: 300, RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: "v=spf1 include:example.net -all"}}}
+			err = zonefile.Export(buf, []zones.Record{record}, opt)
+			Expect(err).To(Succeed())
+			list, err = zonefile.Parse(bytes.NewBufferString(buf.String()), nil)
+		})
+		It("exports one character-string", func() {
+			Expect(buf.String()).To(ContainSubstring("\tTXT\t\"v=spf1 include:example.net -all\"\n"))
+		})
+		It("is parsed as same RRset", func() {
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].RData).To(Equal(zones.RecordRDATASlice{{Value: `"v=spf1 include:example.net -all"`}}))
+			expected, err := record.ToRRs()
+			Expect(err).To(Succeed())
+			imported, err := list.Items[0].ToRRs()
+			Expect(err).To(Succeed())
+			Expect(imported).To(Equal(expected))
+			Expect(list.Items[0].Validate()).To(Succeed())
+		})
+	})
+})
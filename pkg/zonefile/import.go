@@ -0,0 +1,480 @@
+// Package zonefile converts between RFC 1035 master file and zones.Record.
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/types"
+)
+
+const maxIncludeDepth = 7
+
+type ParseOptions struct {
+	// initial $ORIGIN
+	Origin string
+	// file name for diagnostics and base directory of $INCLUDE
+	FileName string
+	// enable $INCLUDE directive. It is disabled by default.
+	AllowInclude bool
+}
+
+// Diagnostic is error of one entry in the zone file.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	if d.File != "" {
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+	return fmt.Sprintf("line %d: %s", d.Line, d.Message)
+}
+
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	res := []string{}
+	for _, diag := range d {
+		res = append(res, diag.Error())
+	}
+	return strings.Join(res, "\n")
+}
+
+// SupportedTypes returns rrtypes which can be imported.
+// SOA is managed by DPF, so it is ignored by Parse.
+func SupportedTypes() []zones.Type {
+	res := make([]zones.Type, 0, len(zones.Types))
+	for _, t := range zones.Types {
+		if t != zones.TypeSOA {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func isSupportedType(t zones.Type) bool {
+	for _, st := range SupportedTypes() {
+		if st == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse reads RFC 1035 master file and returns RecordList grouped into RRsets.
+// Records without TTL use $TTL or last explicit TTL, or null (zone default TTL) if both don't exist.
+// ANAME is written as same format as CNAME.
+// If some entries are invalid, it returns valid records and Diagnostics.
+func Parse(r io.Reader, opt *ParseOptions) (*zones.RecordList, error) {
+	if opt == nil {
+		opt = &ParseOptions{}
+	}
+	p := &parser{
+		opt:    opt,
+		list:   &zones.RecordList{},
+		rrsets: make(map[string]int),
+	}
+	origin := "."
+	if opt.Origin != "" {
+		origin = dns.CanonicalName(opt.Origin)
+	}
+	p.parse(r, opt.FileName, origin, 0)
+	if len(p.diags) > 0 {
+		return p.list, p.diags
+	}
+	return p.list, nil
+}
+
+type parser struct {
+	opt    *ParseOptions
+	list   *zones.RecordList
+	rrsets map[string]int
+	diags  Diagnostics
+
+	prevOwner  string
+	dirTTL     *uint32
+	lastTTL    *uint32
+	lastSetTTL bool
+}
+
+func (p *parser) errorf(file string, line int, format string, args ...interface{}) {
+	p.diags = append(p.diags, Diagnostic{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) parse(r io.Reader, file string, origin string, depth int) {
+	entries, err := splitEntries(r)
+	if err != nil {
+		p.errorf(file, 0, "failed to read zone file: %s", err)
+		return
+	}
+	for _, e := range entries {
+		tokens := tokenize(e.text)
+		if !e.blankOwner && strings.HasPrefix(tokens[0].value, "$") {
+			origin = p.directive(file, e, tokens, origin, depth)
+			continue
+		}
+		p.record(file, e, tokens, origin)
+	}
+}
+
+func (p *parser) directive(file string, e entry, tokens []token, origin string, depth int) string {
+	switch strings.ToUpper(tokens[0].value) {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			p.errorf(file, e.line, "$ORIGIN needs one domain name")
+			return origin
+		}
+		name, ok := absName(tokens[1].value, origin)
+		if !ok {
+			p.errorf(file, e.line, "invalid $ORIGIN `%s`", tokens[1].value)
+			return origin
+		}
+		return name
+	case "$TTL":
+		if len(tokens) != 2 {
+			p.errorf(file, e.line, "$TTL needs one TTL value")
+			return origin
+		}
+		ttl, ok := parseTTL(tokens[1].value)
+		if !ok {
+			p.errorf(file, e.line, "invalid $TTL `%s`", tokens[1].value)
+			return origin
+		}
+		p.dirTTL = &ttl
+	case "$INCLUDE":
+		if !p.opt.AllowInclude {
+			p.errorf(file, e.line, "$INCLUDE is not allowed")
+			return origin
+		}
+		if len(tokens) < 2 || len(tokens) > 3 {
+			p.errorf(file, e.line, "$INCLUDE needs file name and optional origin")
+			return origin
+		}
+		if depth >= maxIncludeDepth {
+			p.errorf(file, e.line, "too deeply nested $INCLUDE")
+			return origin
+		}
+		includeOrigin := origin
+		if len(tokens) == 3 {
+			name, ok := absName(tokens[2].value, origin)
+			if !ok {
+				p.errorf(file, e.line, "invalid $INCLUDE origin `%s`", tokens[2].value)
+				return origin
+			}
+			includeOrigin = name
+		}
+		includeFile := tokens[1].value
+		if !filepath.IsAbs(includeFile) && file != "" {
+			includeFile = filepath.Join(filepath.Dir(file), includeFile)
+		}
+		f, err := os.Open(includeFile)
+		if err != nil {
+			p.errorf(file, e.line, "failed to open $INCLUDE file: %s", err)
+			return origin
+		}
+		defer f.Close()
+		p.parse(f, includeFile, includeOrigin, depth+1)
+	default:
+		p.errorf(file, e.line, "unsupported directive `%s`", tokens[0].value)
+	}
+	return origin
+}
+
+var parseErrorLine = regexp.MustCompile(` at line: (\d+):(\d+)$`)
+
+func (p *parser) record(file string, e entry, tokens []token, origin string) {
+	text := e.text
+	if e.blankOwner {
+		if p.prevOwner == "" {
+			p.errorf(file, e.line, "owner name is not specified")
+			return
+		}
+		text = p.prevOwner + text
+		tokens = tokenize(text)
+	}
+	// find TTL and rrtype token
+	var (
+		ttl        *uint32
+		isANAME    bool
+		typeTokenI = -1
+	)
+	for i := 1; i < len(tokens); i++ {
+		v := tokens[i].value
+		if t, ok := parseTTL(v); ok {
+			ttl = &t
+			continue
+		}
+		if _, ok := dns.StringToClass[strings.ToUpper(v)]; ok || strings.HasPrefix(strings.ToUpper(v), "CLASS") {
+			continue
+		}
+		typeTokenI = i
+		break
+	}
+	if typeTokenI < 0 {
+		p.errorf(file, e.line, "rrtype is not specified")
+		return
+	}
+	if strings.ToUpper(tokens[typeTokenI].value) == string(zones.TypeANAME) {
+		isANAME = true
+		tt := tokens[typeTokenI]
+		text = text[:tt.start] + "CNAME" + text[tt.end:]
+	}
+	switch {
+	case ttl != nil:
+		p.lastTTL = ttl
+	case p.dirTTL != nil:
+		ttl = p.dirTTL
+	default:
+		ttl = p.lastTTL
+	}
+
+	zp := dns.NewZoneParser(strings.NewReader(text), origin, file)
+	zp.SetIncludeAllowed(false)
+	if ttl != nil {
+		zp.SetDefaultTTL(*ttl)
+	} else {
+		zp.SetDefaultTTL(0)
+	}
+	rr, ok := zp.Next()
+	if !ok {
+		msg := "empty record"
+		if err := zp.Err(); err != nil {
+			msg = err.Error()
+			if file != "" {
+				msg = strings.TrimPrefix(msg, file+": ")
+			}
+			if m := parseErrorLine.FindStringSubmatch(msg); m != nil {
+				l, _ := strconv.Atoi(m[1])
+				msg = strings.TrimSuffix(msg, m[0]) + " at line: " + strconv.Itoa(e.line+l-1) + ":" + m[2]
+			}
+		}
+		p.errorf(file, e.line, "%s", msg)
+		return
+	}
+	h := rr.Header()
+	p.prevOwner = h.Name
+	if h.Class != dns.ClassINET {
+		p.errorf(file, e.line, "unsupported class `%s`", dns.ClassToString[h.Class])
+		return
+	}
+	rrtype := zones.Uint16ToType(h.Rrtype)
+	if isANAME {
+		rrtype = zones.TypeANAME
+	}
+	if rrtype == zones.TypeSOA {
+		return
+	}
+	if !isSupportedType(rrtype) {
+		p.errorf(file, e.line, "unsupported rrtype `%s`", dns.Type(h.Rrtype).String())
+		return
+	}
+	rdata := strings.TrimPrefix(rr.String(), h.String())
+	p.add(file, e.line, dns.CanonicalName(h.Name), rrtype, h.Ttl, rdata)
+}
+
+func (p *parser) add(file string, line int, name string, rrtype zones.Type, ttl uint32, rdata string) {
+	key := name + "\t" + string(rrtype)
+	i, ok := p.rrsets[key]
+	if !ok {
+		p.rrsets[key] = len(p.list.Items)
+		p.list.Items = append(p.list.Items, zones.Record{
+			Name:   name,
+			TTL:    types.NullablePositiveInt32(ttl),
+			RRType: rrtype,
+			RData:  zones.RecordRDATASlice{{Value: rdata}},
+		})
+		return
+	}
+	record := &p.list.Items[i]
+	if record.TTL != types.NullablePositiveInt32(ttl) {
+		p.errorf(file, line, "TTL %d is different from TTL %d of RRset %s %s", ttl, record.TTL, name, rrtype)
+		return
+	}
+	for _, v := range record.RData {
+		if v.Value == rdata {
+			return
+		}
+	}
+	record.RData = append(record.RData, zones.RecordRDATA{Value: rdata})
+}
+
+func absName(name string, origin string) (string, bool) {
+	if name == "@" {
+		return origin, true
+	}
+	if !dns.IsFqdn(name) {
+		if origin == "." {
+			name += "."
+		} else {
+			name += "." + origin
+		}
+	}
+	if _, ok := dns.IsDomainName(name); !ok {
+		return "", false
+	}
+	return dns.CanonicalName(name), true
+}
+
+// parseTTL parses TTL value. It supports BIND style units (1w2d3h4m5s).
+func parseTTL(s string) (uint32, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(v), true
+	}
+	var (
+		total uint64
+		num   uint64
+		hasN  bool
+	)
+	for _, c := range strings.ToLower(s) {
+		switch {
+		case c >= '0' && c <= '9':
+			num = num*10 + uint64(c-'0')
+			hasN = true
+		case c == 's' || c == 'm' || c == 'h' || c == 'd' || c == 'w':
+			if !hasN {
+				return 0, false
+			}
+			unit := map[rune]uint64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c]
+			total += num * unit
+			num, hasN = 0, false
+		default:
+			return 0, false
+		}
+		if total+num > 1<<32-1 {
+			return 0, false
+		}
+	}
+	if hasN {
+		return 0, false
+	}
+	return uint32(total), true
+}
+
+type entry struct {
+	line       int
+	text       string
+	blankOwner bool
+}
+
+// splitEntries splits master file into entries. An entry is one line or lines in parentheses.
+// Comments are removed.
+func splitEntries(r io.Reader) ([]entry, error) {
+	var (
+		entries []entry
+		cur     *entry
+		depth   int
+		inQuote bool
+		lineNum int
+		buf     strings.Builder
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if cur == nil {
+			cur = &entry{line: lineNum, blankOwner: len(line) > 0 && (line[0] == ' ' || line[0] == '\t')}
+			buf.Reset()
+		}
+		escaped := false
+	LINE:
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inQuote = !inQuote
+			case inQuote:
+			case c == ';':
+				break LINE
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			}
+			buf.WriteByte(c)
+		}
+		inQuote = false
+		if depth > 0 {
+			buf.WriteByte('\n')
+			continue
+		}
+		depth = 0
+		cur.text = buf.String()
+		if strings.TrimSpace(cur.text) != "" {
+			entries = append(entries, *cur)
+		}
+		cur = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if cur != nil && strings.TrimSpace(buf.String()) != "" {
+		cur.text = buf.String()
+		entries = append(entries, *cur)
+	}
+	return entries, nil
+}
+
+type token struct {
+	value      string
+	start, end int
+}
+
+// tokenize splits entry text by white spaces. Parentheses are ignored.
+// Quoted strings are not separated.
+func tokenize(text string) []token {
+	var (
+		tokens  []token
+		start   = -1
+		inQuote bool
+		escaped bool
+	)
+	for i := 0; i <= len(text); i++ {
+		var c byte = ' '
+		if i < len(text) {
+			c = text[i]
+		}
+		switch {
+		case escaped:
+			escaped = false
+			continue
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			inQuote = !inQuote
+		}
+		sep := !inQuote && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')')
+		if sep {
+			if start >= 0 {
+				tokens = append(tokens, token{value: text[start:i], start: start, end: i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if len(tokens) == 0 {
+		tokens = append(tokens, token{})
+	}
+	return tokens
+}
@@ -0,0 +1,156 @@
+package zonefile_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/zonefile"
+)
+
+var _ = Describe("Parse", func() {
+	var (
+		list  *zones.RecordList
+		err   error
+		diags zonefile.Diagnostics
+		opt   *zonefile.ParseOptions
+	)
+	parse := func(text string) {
+		list, err = zonefile.Parse(strings.NewReader(text), opt)
+		diags = nil
+		if d, ok := err.(zonefile.Diagnostics); ok {
+			diags = d
+		}
+	}
+	BeforeEach(func() {
+		opt = &zonefile.ParseOptions{Origin: "example.jp."}
+	})
+	When("zone file is valid", func() {
+		BeforeEach(func() {
+			parse(`$TTL 1h
+@	IN SOA ns.example.jp. hostmaster.example.jp. (
+		2021010101 ; serial
+		3600 900 604800 300 )
+	IN NS ns1.example.net.
+	IN NS ns2.example.net.
+www	300 IN A 192.168.0.1
+www	300 IN A 192.168.0.2
+	IN AAAA 2001:db8::1
+mail	IN MX 10 mx.example.jp.
+txt	IN TXT "v=spf1 include:example.net -all" "a;b"
+alias	IN ANAME www
+$ORIGIN sub.example.jp.
+host	30 A 192.168.1.1
+_sip._tcp	SRV 0 5 5060 host
+`)
+		})
+		It("returns records", func() {
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(Equal([]zones.Record{
+				{Name: "example.jp.", TTL: 3600, RRType: zones.TypeNS, RData: zones.RecordRDATASlice{{Value: "ns1.example.net."}, {Value: "ns2.example.net."}}},
+				{Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}, {Value: "192.168.0.2"}}},
+				{Name: "www.example.jp.", TTL: 3600, RRType: zones.TypeAAAA, RData: zones.RecordRDATASlice{{Value: "2001:db8::1"}}},
+				{Name: "mail.example.jp.", TTL: 3600, RRType: zones.TypeMX, RData: zones.RecordRDATASlice{{Value: "10 mx.example.jp."}}},
+				{Name: "txt.example.jp.", TTL: 3600, RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `"v=spf1 include:example.net -all" "a;b"`}}},
+				{Name: "alias.example.jp.", TTL: 3600, RRType: zones.TypeANAME, RData: zones.RecordRDATASlice{{Value: "www.example.jp."}}},
+				{Name: "host.sub.example.jp.", TTL: 30, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.1.1"}}},
+				{Name: "_sip._tcp.sub.example.jp.", TTL: 3600, RRType: zones.TypeSRV, RData: zones.RecordRDATASlice{{Value: "0 5 5060 host.sub.example.jp."}}},
+			}))
+		})
+	})
+	When("TTL is not specified", func() {
+		BeforeEach(func() {
+			parse(`www IN A 192.168.0.1
+www2 60 IN A 192.168.0.2
+www3 IN A 192.168.0.3
+`)
+		})
+		It("uses null or last explicit TTL", func() {
+			Expect(err).To(Succeed())
+			Expect(list.Items).To(HaveLen(3))
+			Expect(list.Items[0].TTL).To(BeZero())
+			Expect(list.Items[1].TTL).To(BeEquivalentTo(60))
+			Expect(list.Items[2].TTL).To(BeEquivalentTo(60))
+		})
+	})
+	When("zone file has errors", func() {
+		BeforeEach(func() {
+			parse(`$TTL 1h
+www IN A 192.168.0.1
+bad IN A 192.168.0.300
+hinfo IN HINFO "cpu" "os"
+www 60 IN A 192.168.0.2
+chaos CH TXT "hoge"
+mx IN MX (
+	10
+	)
+$GENERATE 1-10 host$ A 192.168.0.$
+ok IN A 192.168.0.5
+`)
+		})
+		It("returns valid records", func() {
+			Expect(list.Items).To(HaveLen(2))
+			Expect(list.Items[0].Name).To(Equal("www.example.jp."))
+			Expect(list.Items[1].Name).To(Equal("ok.example.jp."))
+		})
+		It("returns diagnostics per line", func() {
+			Expect(diags).To(HaveLen(6))
+			Expect(diags[0].Line).To(Equal(3))
+			Expect(diags[1].Line).To(Equal(4))
+			Expect(diags[1].Message).To(MatchRegexp("unsupported rrtype `HINFO`"))
+			Expect(diags[2].Line).To(Equal(5))
+			Expect(diags[2].Message).To(MatchRegexp("TTL 60 is different"))
+			Expect(diags[3].Line).To(Equal(6))
+			Expect(diags[3].Message).To(MatchRegexp("unsupported class"))
+			Expect(diags[4].Line).To(Equal(7))
+			Expect(diags[4].Message).To(MatchRegexp("bad MX.* at line: 7"))
+			Expect(diags[5].Line).To(Equal(10))
+			Expect(diags[5].Message).To(MatchRegexp("unsupported directive"))
+		})
+	})
+	When("owner name is omitted at first", func() {
+		BeforeEach(func() {
+			parse("  IN A 192.168.0.1\n")
+		})
+		It("returns diagnostic", func() {
+			Expect(diags).To(HaveLen(1))
+			Expect(diags[0].Message).To(MatchRegexp("owner name is not specified"))
+		})
+	})
+	Context("$INCLUDE", func() {
+		When("AllowInclude is false", func() {
+			BeforeEach(func() {
+				opt.FileName = "testdata/example.jp.zone"
+				parse("$INCLUDE include.zone\n")
+			})
+			It("returns diagnostic", func() {
+				Expect(list.Items).To(BeEmpty())
+				Expect(diags).To(HaveLen(1))
+				Expect(diags[0].Error()).To(Equal("testdata/example.jp.zone:1: $INCLUDE is not allowed"))
+			})
+		})
+		When("AllowInclude is true", func() {
+			BeforeEach(func() {
+				opt.FileName = "testdata/example.jp.zone"
+				opt.AllowInclude = true
+				parse("$INCLUDE include.zone sub.example.jp.\nwww IN A 192.168.0.1\n")
+			})
+			It("reads included file", func() {
+				Expect(err).To(Succeed())
+				Expect(list.Items).To(HaveLen(2))
+				Expect(list.Items[0].Name).To(Equal("include.sub.example.jp."))
+				Expect(list.Items[1].Name).To(Equal("www.example.jp."))
+			})
+		})
+	})
+})
+
+var _ = Describe("SupportedTypes", func() {
+	It("returns types without SOA", func() {
+		Expect(zonefile.SupportedTypes()).To(HaveLen(len(zones.Types) - 1))
+		Expect(zonefile.SupportedTypes()).NotTo(ContainElement(zones.TypeSOA))
+		Expect(zonefile.SupportedTypes()).To(ContainElement(zones.TypeANAME))
+	})
+})
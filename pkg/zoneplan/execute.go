@@ -0,0 +1,99 @@
+package zoneplan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/apiutils"
+)
+
+type Result struct {
+	// executed changes
+	Done []Change
+	// job of ZoneApply, it is nil if the zone is not applied.
+	ApplyJob *core.Job
+}
+
+// Execute executes changes of the plan, then applies the zone.
+// It stops at the first error.
+func Execute(ctx context.Context, cl api.ClientInterface, plan *Plan, opt *Options) (*Result, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	res := &Result{}
+	for _, c := range plan.Changes {
+		if err := executeChange(ctx, cl, plan.ZoneID, c); err != nil {
+			return res, fmt.Errorf("failed to %s %s %s: %w", c.Action, c.Name, c.RRType, err)
+		}
+		res.Done = append(res.Done, c)
+	}
+	if !plan.HasChanges() {
+		return res, nil
+	}
+	apply := &zones.ZoneApply{
+		AttributeMeta: zones.AttributeMeta{ZoneID: plan.ZoneID},
+		Description:   opt.Description,
+	}
+	_, job, err := apiutils.SyncApply(ctx, cl, apply, nil)
+	res.ApplyJob = job
+	if err != nil {
+		return res, fmt.Errorf("failed to apply zone: %w", err)
+	}
+	return res, nil
+}
+
+func executeChange(ctx context.Context, cl api.ClientInterface, zoneID string, c Change) error {
+	var err error
+	switch c.Action {
+	case ActionCreate:
+		record := &zones.Record{
+			AttributeMeta: zones.AttributeMeta{ZoneID: zoneID},
+			Name:          c.Desired.Name,
+			TTL:           c.Desired.TTL,
+			RRType:        c.Desired.RRType,
+			RData:         c.Desired.RData,
+			Description:   c.Desired.Description,
+		}
+		_, _, err = apiutils.SyncCreate(ctx, cl, record, nil)
+	case ActionUpdate:
+		record := c.Current.DeepCopy()
+		record.ZoneID = zoneID
+		record.TTL = c.Desired.TTL
+		record.RData = c.Desired.RData
+		if c.Desired.Description != "" {
+			record.Description = c.Desired.Description
+		}
+		_, _, err = apiutils.SyncUpdate(ctx, cl, record, nil)
+	case ActionDelete:
+		record := c.Current.DeepCopy()
+		record.ZoneID = zoneID
+		_, _, err = apiutils.SyncDelete(ctx, cl, record)
+	case ActionCancel:
+		record := c.Current.DeepCopy()
+		record.ZoneID = zoneID
+		_, _, err = apiutils.SyncCancel(ctx, cl, record)
+	default:
+		err = fmt.Errorf("unknown action `%s`", c.Action)
+	}
+	return err
+}
+
+// Reconcile computes the plan and executes it.
+// If opt.DryRun is true, it only returns the plan.
+func Reconcile(ctx context.Context, cl api.ClientInterface, zoneID string, desired []zones.Record, opt *Options) (*Plan, *Result, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	plan, err := Compute(ctx, cl, zoneID, desired, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opt.DryRun {
+		return plan, nil, nil
+	}
+	res, err := Execute(ctx, cl, plan, opt)
+	return plan, res, err
+}
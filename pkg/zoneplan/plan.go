@@ -0,0 +1,284 @@
+// Package zoneplan computes and applies record changes from desired state of a zone.
+package zoneplan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/zonefile"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// cancel pending deletion
+	ActionCancel Action = "cancel"
+)
+
+// Change is a change of RRset keyed by (name, rrtype).
+type Change struct {
+	Action  Action
+	Name    string
+	RRType  zones.Type
+	Current *zones.Record
+	Desired *zones.Record
+}
+
+type Plan struct {
+	ZoneID  string
+	Changes []Change
+	// the number of records which have pending changes before this plan is executed.
+	PendingChanges int
+}
+
+type Options struct {
+	// don't delete records which are not in desired state.
+	NoDelete bool
+	// rrtypes which are not managed. SOA is always ignored.
+	IgnoreTypes []zones.Type
+	// description of ZoneApply
+	Description string
+	// only compute the plan, don't execute it.
+	DryRun bool
+}
+
+func (o *Options) ignore(t zones.Type) bool {
+	if t == zones.TypeSOA {
+		return true
+	}
+	for _, it := range o.IgnoreTypes {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Compute reads records of the zone and returns plan to make the zone desired state.
+func Compute(ctx context.Context, cl api.ClientInterface, zoneID string, desired []zones.Record, opt *Options) (*Plan, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	current := &zones.CurrentRecordList{AttributeMeta: zones.AttributeMeta{ZoneID: zoneID}}
+	if _, err := cl.ListAll(ctx, current, nil); err != nil {
+		return nil, fmt.Errorf("failed to get current records: %w", err)
+	}
+	pending := &zones.RecordList{AttributeMeta: zones.AttributeMeta{ZoneID: zoneID}}
+	if _, err := cl.ListAll(ctx, pending, nil); err != nil {
+		return nil, fmt.Errorf("failed to get records: %w", err)
+	}
+	changes, err := Diff(pending.Items, desired, opt)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{ZoneID: zoneID, Changes: changes}
+	for _, record := range pending.Items {
+		if record.State != zones.RecordStateApplied {
+			plan.PendingChanges++
+		}
+	}
+	if plan.PendingChanges == 0 && !sameRecords(current.Items, pending.Items) {
+		plan.PendingChanges = 1
+	}
+	return plan, nil
+}
+
+// Diff returns changes to make records desired state.
+// records are items of zones.RecordList.
+// If Description of desired record is empty, description is not managed.
+func Diff(records []zones.Record, desired []zones.Record, opt *Options) ([]Change, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	desiredSets := map[string]*zones.Record{}
+	desiredKeys := []string{}
+	for i := range desired {
+		d := desired[i]
+		d.Name = dns.CanonicalName(d.Name)
+		if opt.ignore(d.RRType) {
+			continue
+		}
+		key := rrsetKey(d.Name, d.RRType)
+		if exist, ok := desiredSets[key]; ok {
+			if exist.TTL != d.TTL {
+				return nil, fmt.Errorf("desired RRset %s %s has different TTLs", d.Name, d.RRType)
+			}
+			exist.RData = append(exist.RData, d.RData...)
+			continue
+		}
+		// RData is merged later, so it must not share the backing array of desired.
+		d.RData = append(zones.RecordRDATASlice(nil), d.RData...)
+		desiredSets[key] = &d
+		desiredKeys = append(desiredKeys, key)
+	}
+
+	var (
+		changes  []Change
+		existing = map[string]bool{}
+	)
+	for i := range records {
+		r := records[i]
+		if opt.ignore(r.RRType) || r.State == zones.RecordStateBeforeUpdate {
+			continue
+		}
+		key := rrsetKey(dns.CanonicalName(r.Name), r.RRType)
+		d, ok := desiredSets[key]
+		if !ok {
+			if !opt.NoDelete && r.State != zones.RecordStateToBeDeleted {
+				changes = append(changes, Change{Action: ActionDelete, Name: r.Name, RRType: r.RRType, Current: &r})
+			}
+			continue
+		}
+		existing[key] = true
+		if r.State == zones.RecordStateToBeDeleted {
+			changes = append(changes, Change{Action: ActionCancel, Name: d.Name, RRType: d.RRType, Current: &r, Desired: d})
+		}
+		if !sameRRset(&r, d) {
+			changes = append(changes, Change{Action: ActionUpdate, Name: d.Name, RRType: d.RRType, Current: &r, Desired: d})
+		}
+	}
+	for _, key := range desiredKeys {
+		if existing[key] {
+			continue
+		}
+		d := desiredSets[key]
+		changes = append(changes, Change{Action: ActionCreate, Name: d.Name, RRType: d.RRType, Desired: d})
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return actionOrder(changes[i].Action) < actionOrder(changes[j].Action)
+	})
+	return changes, nil
+}
+
+// cancel and delete are executed before create to avoid conflicts like CNAME and other data.
+func actionOrder(a Action) int {
+	switch a {
+	case ActionCancel:
+		return 0
+	case ActionDelete:
+		return 1
+	case ActionUpdate:
+		return 2
+	}
+	return 3
+}
+
+func rrsetKey(name string, t zones.Type) string {
+	return name + "\t" + string(t)
+}
+
+func sameRRset(current, desired *zones.Record) bool {
+	if current.TTL != desired.TTL {
+		return false
+	}
+	if desired.Description != "" && current.Description != desired.Description {
+		return false
+	}
+	a, b := normalizeRDATA(current), normalizeRDATA(desired)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameRecords(a, b []zones.Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	keys := map[string]*zones.Record{}
+	for i := range a {
+		keys[rrsetKey(dns.CanonicalName(a[i].Name), a[i].RRType)] = &a[i]
+	}
+	for i := range b {
+		r, ok := keys[rrsetKey(dns.CanonicalName(b[i].Name), b[i].RRType)]
+		if !ok || !sameRRset(r, &b[i]) || r.Description != b[i].Description {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeRDATA(r *zones.Record) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, v := range r.RData {
+		value, err := zonefile.FormatRDATA(r.RRType, v.Value)
+		if err != nil {
+			value = strings.TrimSpace(v.Value)
+		}
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		res = append(res, value)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// HasChanges returns true, if the plan has record changes or pending changes.
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0 || p.PendingChanges > 0
+}
+
+// Render writes human readable plan.
+func (p *Plan) Render(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "zone: %s\n", p.ZoneID)
+	if p.PendingChanges > 0 {
+		fmt.Fprintf(b, "! zone has pending changes, they will be applied together\n")
+	}
+	if len(p.Changes) == 0 {
+		fmt.Fprintf(b, "no record changes\n")
+	}
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(b, "+ create %s %s ttl=%s rdata=[%s]\n", c.Name, c.RRType, ttlString(c.Desired), c.Desired.RData.String())
+		case ActionDelete:
+			fmt.Fprintf(b, "- delete %s %s ttl=%s rdata=[%s]\n", c.Name, c.RRType, ttlString(c.Current), c.Current.RData.String())
+		case ActionCancel:
+			fmt.Fprintf(b, "* cancel deletion %s %s\n", c.Name, c.RRType)
+		case ActionUpdate:
+			fmt.Fprintf(b, "~ update %s %s\n", c.Name, c.RRType)
+			if c.Current.TTL != c.Desired.TTL {
+				fmt.Fprintf(b, "    ttl: %s -> %s\n", ttlString(c.Current), ttlString(c.Desired))
+			}
+			if strings.Join(normalizeRDATA(c.Current), ",") != strings.Join(normalizeRDATA(c.Desired), ",") {
+				fmt.Fprintf(b, "    rdata: [%s] -> [%s]\n", c.Current.RData.String(), c.Desired.RData.String())
+			}
+			if c.Desired.Description != "" && c.Current.Description != c.Desired.Description {
+				fmt.Fprintf(b, "    description: %q -> %q\n", c.Current.Description, c.Desired.Description)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (p *Plan) String() string {
+	b := &strings.Builder{}
+	_ = p.Render(b)
+	return b.String()
+}
+
+func ttlString(r *zones.Record) string {
+	if r.TTL == 0 {
+		return "default"
+	}
+	return fmt.Sprintf("%d", r.TTL)
+}
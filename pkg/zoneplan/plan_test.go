@@ -0,0 +1,234 @@
+package zoneplan_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/mimuret/golang-iij-dpf/pkg/api"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/core"
+	"github.com/mimuret/golang-iij-dpf/pkg/apis/dpf/v1/zones"
+	"github.com/mimuret/golang-iij-dpf/pkg/testtool"
+	"github.com/mimuret/golang-iij-dpf/pkg/zoneplan"
+)
+
+var _ = Describe("zoneplan", func() {
+	var (
+		records []zones.Record
+		current []zones.Record
+		desired []zones.Record
+		changes []zoneplan.Change
+		plan    *zoneplan.Plan
+		res     *zoneplan.Result
+		opt     *zoneplan.Options
+		c       *testtool.TestClient
+		calls   []string
+		err     error
+	)
+	BeforeEach(func() {
+		records = []zones.Record{
+			{ID: "r0", Name: "example.jp.", TTL: 3600, RRType: zones.TypeSOA, RData: zones.RecordRDATASlice{{Value: "ns000.d-53.net. dns-managers.iij.ad.jp. 30 3600 600 604800 900"}}},
+			{ID: "r1", Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.1"}, {Value: "192.168.0.2"}}, Description: "web"},
+			{ID: "r2", Name: "www.example.jp.", TTL: 300, RRType: zones.TypeAAAA, RData: zones.RecordRDATASlice{{Value: "2001:db8::1"}}},
+			{ID: "r3", Name: "old.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.3"}}},
+			{ID: "r4", Name: "mail.example.jp.", TTL: 300, RRType: zones.TypeMX, RData: zones.RecordRDATASlice{{Value: "10 mx.example.jp."}}, State: zones.RecordStateToBeDeleted},
+		}
+		current = records
+		desired = []zones.Record{
+			{Name: "WWW.example.jp", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.2"}, {Value: "192.168.0.1"}}},
+			{Name: "www.example.jp.", TTL: 600, RRType: zones.TypeAAAA, RData: zones.RecordRDATASlice{{Value: "2001:db8::1"}}},
+			{Name: "mail.example.jp.", TTL: 300, RRType: zones.TypeMX, RData: zones.RecordRDATASlice{{Value: "10 mx.example.jp."}}},
+			{Name: "new.example.jp.", RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `"hoge"`}}},
+		}
+		opt = &zoneplan.Options{}
+		calls = nil
+		c = testtool.NewTestClient("token", "http://localhost", nil)
+		c.ListAllFunc = func(s api.CountableListSpec, keywords api.SearchParams) (string, error) {
+			switch l := s.(type) {
+			case *zones.CurrentRecordList:
+				l.Items = current
+			case *zones.RecordList:
+				l.Items = records
+			default:
+				return "", fmt.Errorf("unexpected spec %T", s)
+			}
+			return "", nil
+		}
+		c.ReadFunc = func(s api.Spec) (string, error) {
+			job := s.(*core.Job)
+			job.Status = core.JobStatusSuccessful
+			return job.RequestID, nil
+		}
+		record := func(action string) func(s api.Spec) (string, error) {
+			return func(s api.Spec) (string, error) {
+				r := s.(*zones.Record)
+				calls = append(calls, fmt.Sprintf("%s %s %s %s", action, r.ZoneID, r.Name, r.RRType))
+				return "req", nil
+			}
+		}
+		c.CreateFunc = func(s api.Spec, body interface{}) (string, error) { return record("create")(s) }
+		c.UpdateFunc = func(s api.Spec, body interface{}) (string, error) { return record("update")(s) }
+		c.DeleteFunc = record("delete")
+		c.CancelFunc = record("cancel")
+		c.ApplyFunc = func(s api.Spec, body interface{}) (string, error) {
+			a := s.(*zones.ZoneApply)
+			calls = append(calls, fmt.Sprintf("apply %s %s", a.ZoneID, a.Description))
+			return "req", nil
+		}
+	})
+	Context("Diff", func() {
+		BeforeEach(func() {
+			changes, err = zoneplan.Diff(records, desired, opt)
+		})
+		It("returns changes", func() {
+			Expect(err).To(Succeed())
+			Expect(changes).To(HaveLen(4))
+			Expect(changes[0].Action).To(Equal(zoneplan.ActionCancel))
+			Expect(changes[0].Name).To(Equal("mail.example.jp."))
+			Expect(changes[1].Action).To(Equal(zoneplan.ActionDelete))
+			Expect(changes[1].Current.ID).To(Equal("r3"))
+			Expect(changes[2].Action).To(Equal(zoneplan.ActionUpdate))
+			Expect(changes[2].Current.ID).To(Equal("r2"))
+			Expect(changes[2].Desired.TTL).To(BeEquivalentTo(600))
+			Expect(changes[3].Action).To(Equal(zoneplan.ActionCreate))
+			Expect(changes[3].Name).To(Equal("new.example.jp."))
+		})
+		When("NoDelete is true", func() {
+			BeforeEach(func() {
+				opt.NoDelete = true
+				changes, err = zoneplan.Diff(records, desired, opt)
+			})
+			It("does not delete records", func() {
+				Expect(err).To(Succeed())
+				for _, c := range changes {
+					Expect(c.Action).NotTo(Equal(zoneplan.ActionDelete))
+				}
+			})
+		})
+		When("description is changed", func() {
+			BeforeEach(func() {
+				desired[0].Description = "web server"
+				changes, err = zoneplan.Diff(records, desired[:1], &zoneplan.Options{NoDelete: true})
+			})
+			It("returns update", func() {
+				Expect(err).To(Succeed())
+				Expect(changes).To(HaveLen(1))
+				Expect(changes[0].Action).To(Equal(zoneplan.ActionUpdate))
+			})
+		})
+		When("desired RRset is separated", func() {
+			var first zones.RecordRDATASlice
+			BeforeEach(func() {
+				first = make(zones.RecordRDATASlice, 1, 4)
+				first[0] = zones.RecordRDATA{Value: "192.168.0.1"}
+				desired = []zones.Record{
+					{Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: first},
+					{Name: "www.example.jp.", TTL: 300, RRType: zones.TypeA, RData: zones.RecordRDATASlice{{Value: "192.168.0.3"}}},
+				}
+				changes, err = zoneplan.Diff(records, desired, &zoneplan.Options{NoDelete: true})
+			})
+			It("merges rdata", func() {
+				Expect(err).To(Succeed())
+				Expect(changes).To(HaveLen(1))
+				Expect(changes[0].Action).To(Equal(zoneplan.ActionUpdate))
+				Expect(changes[0].Desired.RData).To(Equal(zones.RecordRDATASlice{{Value: "192.168.0.1"}, {Value: "192.168.0.3"}}))
+			})
+			It("doesn't modify desired", func() {
+				Expect(first[:2][1]).To(Equal(zones.RecordRDATA{}))
+				Expect(desired[0].RData).To(HaveLen(1))
+			})
+		})
+		When("desired RRset has different TTLs", func() {
+			BeforeEach(func() {
+				desired = append(desired, zones.Record{Name: "new.example.jp.", TTL: 30, RRType: zones.TypeTXT, RData: zones.RecordRDATASlice{{Value: `"fuga"`}}})
+				changes, err = zoneplan.Diff(records, desired, opt)
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+	Context("Reconcile", func() {
+		When("dry-run", func() {
+			BeforeEach(func() {
+				opt.DryRun = true
+				plan, res, err = zoneplan.Reconcile(context.Background(), c, "m1", desired, opt)
+			})
+			It("returns plan without executing", func() {
+				Expect(err).To(Succeed())
+				Expect(res).To(BeNil())
+				Expect(calls).To(BeEmpty())
+				Expect(plan.PendingChanges).To(Equal(1))
+			})
+			It("renders plan", func() {
+				Expect(plan.String()).To(Equal(`zone: m1
+! zone has pending changes, they will be applied together
+* cancel deletion mail.example.jp. MX
+- delete old.example.jp. A ttl=300 rdata=[192.168.0.3]
+~ update www.example.jp. AAAA
+    ttl: 300 -> 600
++ create new.example.jp. TXT ttl=default rdata=["hoge"]
+`))
+			})
+		})
+		When("executes", func() {
+			BeforeEach(func() {
+				opt.Description = "by zoneplan"
+				plan, res, err = zoneplan.Reconcile(context.Background(), c, "m1", desired, opt)
+			})
+			It("executes changes and applies zone", func() {
+				Expect(err).To(Succeed())
+				Expect(res.Done).To(HaveLen(4))
+				Expect(res.ApplyJob).NotTo(BeNil())
+				Expect(calls).To(Equal([]string{
+					"cancel m1 mail.example.jp. MX",
+					"delete m1 old.example.jp. A",
+					"update m1 www.example.jp. AAAA",
+					"create m1 new.example.jp. TXT",
+					"apply m1 by zoneplan",
+				}))
+			})
+		})
+		When("no changes", func() {
+			BeforeEach(func() {
+				records = records[:3]
+				current = records
+				plan, res, err = zoneplan.Reconcile(context.Background(), c, "m1", records, opt)
+			})
+			It("does not apply", func() {
+				Expect(err).To(Succeed())
+				Expect(plan.HasChanges()).To(BeFalse())
+				Expect(res.ApplyJob).To(BeNil())
+				Expect(calls).To(BeEmpty())
+				Expect(plan.String()).To(MatchRegexp("no record changes"))
+			})
+		})
+		When("change failed", func() {
+			BeforeEach(func() {
+				c.DeleteFunc = func(s api.Spec) (string, error) {
+					return "", fmt.Errorf("delete error")
+				}
+				plan, res, err = zoneplan.Reconcile(context.Background(), c, "m1", desired, opt)
+			})
+			It("stops at the error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(MatchRegexp("failed to delete old.example.jp. A: delete error"))
+				Expect(res.Done).To(HaveLen(1))
+			})
+		})
+		When("failed to list records", func() {
+			BeforeEach(func() {
+				c.ListAllFunc = func(s api.CountableListSpec, keywords api.SearchParams) (string, error) {
+					return "", fmt.Errorf("list error")
+				}
+				plan, res, err = zoneplan.Reconcile(context.Background(), c, "m1", desired, opt)
+			})
+			It("returns error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(plan).To(BeNil())
+			})
+		})
+	})
+})